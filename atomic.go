@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ==================== ATOMIC WRITES ====================
+
+// syncFile and renameFile are the steps of atomicWrite that can fail after
+// the data is written; tests replace them to simulate a failing disk
+var (
+	syncFile   = (*os.File).Sync
+	renameFile = os.Rename
+)
+
+// atomicWrite replaces the file at path with the output of write. The data is
+// written to a temporary file in the same directory, flushed to disk, given
+// the original file's mode (or mode for new files) and renamed over the target,
+// so readers see either the old or the new contents and never a partial file.
+// Symlinks are followed so the link itself is preserved.
+func atomicWrite(path string, mode os.FileMode, write func(w io.Writer) error) (err error) {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode()
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".gxtmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err = write(tmp); err != nil {
+		return err
+	}
+	if err = syncFile(tmp); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), mode.Perm()); err != nil {
+		return err
+	}
+	if err = renameFile(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	syncDir(dir)
+	return nil
+}
+
+// atomicWriteFile atomically replaces path with data
+func atomicWriteFile(path string, data []byte, mode os.FileMode) error {
+	return atomicWrite(path, mode, func(w io.Writer) error {
+		_, err := io.Copy(w, bytes.NewReader(data))
+		return err
+	})
+}
+
+// syncDir flushes a directory entry to disk so a rename survives a crash.
+// It is best effort: some platforms cannot sync directories.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var errInjected = errors.New("injected failure")
+
+// TestAtomicWriteFailures checks that a failure at any step leaves the
+// original file untouched and no temporary file behind
+func TestAtomicWriteFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		write  func(w io.Writer) error
+		sync   func(*os.File) error
+		rename func(string, string) error
+	}{
+		{
+			name: "writer fails mid-write",
+			write: func(w io.Writer) error {
+				io.WriteString(w, "partial new cont")
+				return errInjected
+			},
+		},
+		{
+			name: "fsync fails",
+			sync: func(*os.File) error { return errInjected },
+		},
+		{
+			name:   "rename fails",
+			rename: func(string, string) error { return errInjected },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "data.txt")
+			if err := os.WriteFile(path, []byte("original contents\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.sync != nil {
+				syncFile = tt.sync
+				defer func() { syncFile = (*os.File).Sync }()
+			}
+			if tt.rename != nil {
+				renameFile = tt.rename
+				defer func() { renameFile = os.Rename }()
+			}
+			write := tt.write
+			if write == nil {
+				write = func(w io.Writer) error {
+					_, err := io.WriteString(w, "new contents\n")
+					return err
+				}
+			}
+
+			if err := atomicWrite(path, 0644, write); !errors.Is(err, errInjected) {
+				t.Fatalf("atomicWrite error = %v, want the injected failure", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != "original contents\n" {
+				t.Errorf("original file changed to %q", data)
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				var names []string
+				for _, e := range entries {
+					names = append(names, e.Name())
+				}
+				t.Errorf("directory holds %v, want only data.txt", names)
+			}
+		})
+	}
+}
+
+// TestAtomicWriteReplaces checks the successful path keeps the file's mode
+func TestAtomicWriteReplaces(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := atomicWriteFile(path, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("contents = %q, want %q", data, "new")
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,408 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==================== AUDIT LOG ====================
+
+// The audit log is an append-only JSON-lines file. Each entry stores the hash
+// of the entry before it, so editing, reordering or removing a line breaks the
+// chain. The hash of the newest entry is also kept in a separate head file so
+// that truncating the end of the log is detected as well.
+//
+// The chain is not keyed: anyone who can write the log can also recompute
+// every hash and the head, so verification catches accidental or careless
+// edits, not a deliberate forger with write access. Ship the log elsewhere
+// for that. Appends are serialized with a lock on the log file so two
+// shells writing at once cannot fork the chain.
+
+const (
+	auditLogName  = "audit.log"
+	auditHeadName = "audit.head"
+	auditGenesis  = "0000000000000000000000000000000000000000000000000000000000000000"
+)
+
+// auditEntry is a single record in the audit log
+type auditEntry struct {
+	Seq     int64    `json:"seq"`
+	Time    string   `json:"time"`
+	User    string   `json:"user"`
+	Cwd     string   `json:"cwd"`
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	Outcome string   `json:"outcome"`
+	Bytes   int64    `json:"bytes"`
+	Prev    string   `json:"prev"`
+	Hash    string   `json:"hash"`
+}
+
+// computeHash returns the chained hash of an entry (ignoring its Hash field)
+func (e auditEntry) computeHash() string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(append([]byte(e.Prev+"\n"), data...))
+	return hex.EncodeToString(sum[:])
+}
+
+// auditLogPath returns the location of the audit log (GX_AUDIT_LOG overrides it)
+func auditLogPath() (string, error) {
+	if path := os.Getenv("GX_AUDIT_LOG"); path != "" {
+		return path, nil
+	}
+	return configPath(auditLogName)
+}
+
+// auditHeadPath returns the head file stored next to the audit log
+func auditHeadPath(logPath string) string {
+	return filepath.Join(filepath.Dir(logPath), auditHeadName)
+}
+
+// auditPaths resolves path arguments to absolute paths for the audit record
+func auditPaths(paths ...string) []string {
+	resolved := make([]string, len(paths))
+	for i, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			abs = p
+		}
+		resolved[i] = abs
+	}
+	return resolved
+}
+
+// currentUsername returns the name of the user running the shell
+func currentUsername() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// recordAudit appends an entry for a mutating command to the audit log.
+// Failures to write the log are reported but never block the command itself.
+// Dry runs and cancelled operations change nothing and are not recorded.
+func recordAudit(command string, args []string, bytesAffected int64, opErr error) {
+	if isDryRun() || opErr == errCancelled {
+		return
+	}
+
+	outcome := "ok"
+	if opErr != nil {
+		outcome = "error: " + opErr.Error()
+	}
+
+	if err := appendAudit(command, args, outcome, bytesAffected); err != nil {
+		fmt.Printf("⚠️  Warning: could not write audit log: %v\n", err)
+	}
+}
+
+// appendAudit writes a new chained entry to the audit log
+func appendAudit(command string, args []string, outcome string, bytesAffected int64) error {
+	logPath, err := auditLogPath()
+	if err != nil {
+		return err
+	}
+
+	// Hold the lock from reading the head until the new head is written
+	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := lockFile(file); err != nil {
+		return err
+	}
+	defer unlockFile(file)
+
+	seq, prev, err := auditTail(logPath)
+	if err != nil {
+		return err
+	}
+
+	cwd, _ := os.Getwd()
+	entry := auditEntry{
+		Seq:     seq + 1,
+		Time:    time.Now().UTC().Format(time.RFC3339Nano),
+		User:    currentUsername(),
+		Cwd:     cwd,
+		Command: command,
+		Args:    args,
+		Outcome: outcome,
+		Bytes:   bytesAffected,
+		Prev:    prev,
+	}
+	entry.Hash = entry.computeHash()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	head := fmt.Sprintf("%d %s\n", entry.Seq, entry.Hash)
+	return atomicWriteFile(auditHeadPath(logPath), []byte(head), 0600)
+}
+
+// auditTail returns the sequence number and hash of the newest entry.
+// The head file is used when present; otherwise the log is scanned.
+func auditTail(logPath string) (int64, string, error) {
+	if data, err := os.ReadFile(auditHeadPath(logPath)); err == nil {
+		if seq, hash, ok := parseAuditHead(data); ok {
+			return seq, hash, nil
+		}
+	}
+
+	var last *auditEntry
+	err := readAuditLog(logPath, func(_ int, e *auditEntry, perr error) bool {
+		if perr == nil {
+			last = e
+		}
+		return true
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return 0, "", err
+	}
+	if last == nil {
+		return 0, auditGenesis, nil
+	}
+	return last.Seq, last.Hash, nil
+}
+
+// parseAuditHead parses the "seq hash" contents of the head file
+func parseAuditHead(data []byte) (int64, string, bool) {
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 {
+		return 0, "", false
+	}
+	seq, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return seq, fields[1], true
+}
+
+// readAuditLog calls fn for every line of the log. Lines that fail to parse are
+// passed with a non-nil error. Returning false from fn stops the scan.
+func readAuditLog(logPath string, fn func(lineNum int, e *auditEntry, err error) bool) error {
+	file, err := os.Open(logPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	lineNum := 0
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			lineNum++
+			line = bytes.TrimRight(line, "\r\n")
+			var entry auditEntry
+			perr := json.Unmarshal(line, &entry)
+			if !fn(lineNum, &entry, perr) {
+				return nil
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// verifyAuditLog walks the hash chain and reports the first inconsistency
+func verifyAuditLog(logPath string) (int, error) {
+	prev := auditGenesis
+	var expectSeq int64 = 1
+	count := 0
+	var chainErr error
+
+	err := readAuditLog(logPath, func(lineNum int, e *auditEntry, perr error) bool {
+		switch {
+		case perr != nil:
+			chainErr = fmt.Errorf("line %d: unreadable entry: %v", lineNum, perr)
+		case e.Seq != expectSeq:
+			chainErr = fmt.Errorf("line %d: expected sequence %d, found %d", lineNum, expectSeq, e.Seq)
+		case e.Prev != prev:
+			chainErr = fmt.Errorf("line %d (seq %d): previous-hash link is broken", lineNum, e.Seq)
+		case e.computeHash() != e.Hash:
+			chainErr = fmt.Errorf("line %d (seq %d): entry contents were modified", lineNum, e.Seq)
+		}
+		if chainErr != nil {
+			return false
+		}
+		prev = e.Hash
+		expectSeq++
+		count++
+		return true
+	})
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return count, err
+	}
+	if chainErr != nil {
+		return count, chainErr
+	}
+
+	data, err := os.ReadFile(auditHeadPath(logPath))
+	if err != nil {
+		if os.IsNotExist(err) && count == 0 {
+			return 0, nil
+		}
+		return count, fmt.Errorf("head file missing or unreadable: %v", err)
+	}
+	seq, hash, ok := parseAuditHead(data)
+	if !ok {
+		return count, fmt.Errorf("head file is corrupted")
+	}
+	if seq != int64(count) || hash != prev {
+		return count, fmt.Errorf("log ends at entry %d but head records entry %d (entries removed or appended out of band)", count, seq)
+	}
+	return count, nil
+}
+
+// auditQuery holds the filters accepted by gxaudit
+type auditQuery struct {
+	limit   int
+	command string
+	user    string
+	since   time.Time
+	failed  bool
+}
+
+// gxaudit queries or verifies the audit log
+func gxaudit(args []string) {
+	logPath, err := auditLogPath()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	if len(args) > 0 && args[0] == "verify" {
+		count, err := verifyAuditLog(logPath)
+		if err != nil {
+			fmt.Printf("❌ Audit log verification FAILED after %d valid entries: %v\n", count, err)
+			return
+		}
+		fmt.Printf("✅ Audit log intact (%d entries)\n", count)
+		return
+	}
+
+	query := auditQuery{limit: 20}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "-n", "-c", "-u", "--since":
+			if i+1 >= len(args) {
+				fmt.Printf("Error: Missing value for %s\n", arg)
+				return
+			}
+			i++
+			value := args[i]
+			switch arg {
+			case "-n":
+				n, err := strconv.Atoi(value)
+				if err != nil || n < 0 {
+					fmt.Printf("Error: Invalid count '%s'\n", value)
+					return
+				}
+				query.limit = n
+			case "-c":
+				query.command = value
+			case "-u":
+				query.user = value
+			case "--since":
+				t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+				if err != nil {
+					fmt.Printf("Error: Invalid date '%s' (use YYYY-MM-DD)\n", value)
+					return
+				}
+				query.since = t
+			}
+		case "--failed":
+			query.failed = true
+		default:
+			fmt.Printf("Error: Unknown option '%s'\n", arg)
+			fmt.Println("Usage: gxaudit [verify] [-n N] [-c command] [-u user] [--since YYYY-MM-DD] [--failed]")
+			return
+		}
+	}
+
+	var matches []*auditEntry
+	err = readAuditLog(logPath, func(_ int, e *auditEntry, perr error) bool {
+		if perr == nil && query.matches(e) {
+			matches = append(matches, e)
+		}
+		return true
+	})
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("Audit log is empty")
+			return
+		}
+		fmt.Printf("Error reading audit log: %v\n", err)
+		return
+	}
+
+	if len(matches) == 0 {
+		fmt.Println("No matching audit entries")
+		return
+	}
+
+	if query.limit > 0 && len(matches) > query.limit {
+		matches = matches[len(matches)-query.limit:]
+	}
+
+	fmt.Printf("--- Audit log: %s ---\n", logPath)
+	for _, e := range matches {
+		ts := e.Time
+		if t, err := time.Parse(time.RFC3339Nano, e.Time); err == nil {
+			ts = t.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("#%-5d %s  %-10s %-10s %s\n", e.Seq, ts, e.User, e.Command, strings.Join(e.Args, " "))
+		fmt.Printf("       cwd: %s  outcome: %s  bytes: %d\n", e.Cwd, e.Outcome, e.Bytes)
+	}
+	fmt.Printf("--- Showed %d entries ---\n", len(matches))
+}
+
+// matches reports whether an entry passes the query filters
+func (q auditQuery) matches(e *auditEntry) bool {
+	if q.command != "" && e.Command != q.command {
+		return false
+	}
+	if q.user != "" && e.User != q.user {
+		return false
+	}
+	if q.failed && e.Outcome == "ok" {
+		return false
+	}
+	if !q.since.IsZero() {
+		t, err := time.Parse(time.RFC3339Nano, e.Time)
+		if err != nil || t.Before(q.since) {
+			return false
+		}
+	}
+	return true
+}
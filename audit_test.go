@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeAuditEntries starts a fresh audit log with n entries and returns its path
+func writeAuditEntries(t *testing.T, n int) string {
+	t.Helper()
+	logPath := filepath.Join(t.TempDir(), "audit.log")
+	t.Setenv("GX_AUDIT_LOG", logPath)
+	for i := 0; i < n; i++ {
+		if err := appendAudit("gx", []string{"file" + string(rune('a'+i))}, "ok", int64(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return logPath
+}
+
+func TestVerifyAuditLog(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(lines []string, logPath string) []string
+		valid  int    // entries that still verify before the break
+		report string // how the first broken entry is reported
+	}{
+		{"untouched", func(lines []string, _ string) []string { return lines }, 5, ""},
+		{"edited entry", func(lines []string, _ string) []string {
+			lines[2] = strings.Replace(lines[2], `"filec"`, `"other"`, 1)
+			return lines
+		}, 2, "seq 3"},
+		{"edited outcome", func(lines []string, _ string) []string {
+			lines[3] = strings.Replace(lines[3], `"ok"`, `"error: x"`, 1)
+			return lines
+		}, 3, "seq 4"},
+		{"deleted entry", func(lines []string, _ string) []string {
+			return append(lines[:1], lines[2:]...)
+		}, 1, "expected sequence 2"},
+		{"reordered entries", func(lines []string, _ string) []string {
+			lines[2], lines[3] = lines[3], lines[2]
+			return lines
+		}, 2, "expected sequence 3"},
+		{"unreadable entry", func(lines []string, _ string) []string {
+			lines[0] = lines[0][:10]
+			return lines
+		}, 0, "line 1"},
+		{"last entry removed", func(lines []string, _ string) []string {
+			return lines[:4]
+		}, 4, "head records entry 5"},
+		{"head file truncated", func(lines []string, logPath string) []string {
+			os.WriteFile(auditHeadPath(logPath), nil, 0600)
+			return lines
+		}, 5, "head file is corrupted"},
+		{"head file removed", func(lines []string, logPath string) []string {
+			os.Remove(auditHeadPath(logPath))
+			return lines
+		}, 5, "head file missing"},
+	}
+	for _, tt := range tests {
+		logPath := writeAuditEntries(t, 5)
+		data, err := os.ReadFile(logPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := tt.tamper(strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"), logPath)
+		if err := os.WriteFile(logPath, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		valid, err := verifyAuditLog(logPath)
+		if valid != tt.valid {
+			t.Errorf("%s: %d entries verified, want %d", tt.name, valid, tt.valid)
+		}
+		switch {
+		case tt.report == "" && err != nil:
+			t.Errorf("%s: verify failed: %v", tt.name, err)
+		case tt.report != "" && (err == nil || !strings.Contains(err.Error(), tt.report)):
+			t.Errorf("%s: verify error = %v, want one mentioning %q", tt.name, err, tt.report)
+		}
+	}
+}
+
+func TestAuditAppendContinuesChain(t *testing.T) {
+	logPath := writeAuditEntries(t, 2)
+	// Without the head file the tail is found by scanning the log
+	os.Remove(auditHeadPath(logPath))
+	if err := appendAudit("gxd", nil, "ok", 0); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := verifyAuditLog(logPath); n != 3 || err != nil {
+		t.Errorf("verify after append = %d, %v; want 3 entries and no error", n, err)
+	}
+}
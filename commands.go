@@ -2,8 +2,6 @@ package main
 
 import (
 	"bufio"
-	"crypto/md5"
-	"crypto/sha1"
 	"fmt"
 	"io"
 	"os"
@@ -18,17 +16,26 @@ import (
 // ==================== FILE OPERATIONS ====================
 
 // createItem creates a new file (if name contains ".") or directory
-func createItem(name string) {
+func createItem(name string) (int64, error) {
 	// Security check
 	if !validateFilename(name) {
-		return
+		return 0, errInvalidInput
+	}
+
+	if isDryRun() {
+		kind := "folder"
+		if strings.Contains(name, ".") {
+			kind = "file"
+		}
+		dryRunf("would create %s '%s'", kind, name)
+		return 0, nil
 	}
 
 	if strings.Contains(name, ".") {
 		file, err := os.Create(name)
 		if err != nil {
 			fmt.Println("Error:", err)
-			return
+			return 0, err
 		}
 		file.Close()
 		fmt.Printf("📄 File '%s' created.\n", name)
@@ -36,31 +43,70 @@ func createItem(name string) {
 		err := os.Mkdir(name, 0755)
 		if err != nil {
 			fmt.Println("Error:", err)
-			return
+			return 0, err
 		}
 		fmt.Printf("📁 Folder '%s' created.\n", name)
 	}
+	recordJournal(journalCreate, "gx", name, "", journalSnap{})
+	return 0, nil
 }
 
-// deleteItem removes a file or directory recursively
-func deleteItem(name string) {
+// deleteItem moves a file or directory to the trash, or removes it
+// permanently (recursively) when force is set
+func deleteItem(name string, force bool) (int64, error) {
 	// Security check
-	if !validateFilename(name) {
-		return
+	if !validateTarget(name) {
+		return 0, errInvalidInput
 	}
 
 	// Additional safety check - prevent deleting system files
 	if isSuspiciousPath(name) {
 		fmt.Println("❌ Error: Access denied - Cannot delete this path")
-		return
+		return 0, errInvalidInput
 	}
 
-	err := os.RemoveAll(name)
+	size, err := dirSize(name)
 	if err != nil {
 		fmt.Println("Error:", err)
-		return
+		return 0, err
+	}
+
+	if isDryRun() {
+		if force {
+			dryRunf("would permanently delete '%s' (%d bytes)", name, size)
+		} else {
+			dryRunf("would move '%s' (%d bytes) to trash", name, size)
+		}
+		return 0, nil
 	}
-	fmt.Printf("🗑️ '%s' deleted.\n", name)
+
+	if force {
+		if !confirmAction("Permanently delete '%s' (%d bytes)? This cannot be undone.", name, size) {
+			return 0, errCancelled
+		}
+	} else if !confirmAction("Move '%s' to trash?", name) {
+		return 0, errCancelled
+	}
+
+	if !force {
+		before := journalSnap{State: statePath(name)}
+		id, err := moveToTrash(name)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return 0, err
+		}
+		recordTrashJournal("gxd", name, id, before)
+		fmt.Printf("🗑️ '%s' moved to trash (restore with: gxtrash restore %s)\n", name, id)
+		return size, nil
+	}
+
+	err = os.RemoveAll(name)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return 0, err
+	}
+	fmt.Printf("🗑️ '%s' permanently deleted.\n", name)
+	return size, nil
 }
 
 // changeDir changes the current working directory
@@ -77,160 +123,226 @@ func changeDir(path string) {
 }
 
 // moveFile moves or renames a file from source to destination
-func moveFile(src, dst string) {
+func moveFile(src, dst string) (int64, error) {
 	// Security checks
 	if !validatePath(src) || !validatePath(dst) {
-		return
+		return 0, errInvalidInput
 	}
 
 	if !validateFilename(filepath.Base(dst)) {
-		return
+		return 0, errInvalidInput
+	}
+
+	size, _ := dirSize(src)
+
+	_, statErr := os.Stat(dst)
+	overwrite := statErr == nil
+
+	if isDryRun() {
+		if overwrite {
+			dryRunf("would move '%s' to '%s' (%d bytes), overwriting the existing '%s'", src, dst, size, dst)
+		} else {
+			dryRunf("would move '%s' to '%s' (%d bytes)", src, dst, size)
+		}
+		return 0, nil
+	}
+
+	if overwrite && !confirmAction("'%s' already exists. Overwrite it?", dst) {
+		return 0, errCancelled
 	}
 
-	err := os.Rename(src, dst)
+	before := journalCapture(dst)
+	err := movePath(src, dst)
 	if err != nil {
 		fmt.Printf("Error moving '%s' to '%s': %v\n", src, dst, err)
-		return
+		return 0, err
 	}
+	recordJournal(journalMove, "gxmv", dst, src, before)
 	fmt.Printf("✅ Moved '%s' to '%s'\n", src, dst)
+	return size, nil
 }
 
-// copyFile copies a file from source to destination
-func copyFile(src, dst string) {
+// copyFile copies a file, or a whole directory tree with -r, from source to destination.
+// Contents are streamed and the source mode, mtime and symlinks are preserved.
+func copyFile(src, dst string, opts copyOptions) (int64, error) {
 	// Security checks
 	if !validatePath(src) || !validatePath(dst) {
-		return
+		return 0, errInvalidInput
 	}
 
 	if !validateFilename(filepath.Base(dst)) {
-		return
+		return 0, errInvalidInput
 	}
 
-	// Check file size before copying
 	info, err := os.Stat(src)
 	if err != nil {
-		fmt.Printf("Error accessing source file '%s': %v\n", src, err)
-		return
+		fmt.Printf("Error accessing source '%s': %v\n", src, err)
+		return 0, err
 	}
 
-	if !checkFileSizeLimit(info.Size()) {
-		return
+	if info.IsDir() && !opts.recursive {
+		fmt.Printf("Error: '%s' is a directory (use gxcp -r to copy directories)\n", src)
+		return 0, errInvalidInput
 	}
 
-	data, err := os.ReadFile(src)
-	if err != nil {
-		fmt.Printf("Error reading source file '%s': %v\n", src, err)
-		return
+	// Copying onto an existing directory places the source inside it
+	if dstInfo, err := os.Stat(dst); err == nil && dstInfo.IsDir() {
+		dst = filepath.Join(dst, filepath.Base(src))
 	}
 
-	err = os.WriteFile(dst, data, 0644)
-	if err != nil {
-		fmt.Printf("Error writing to destination '%s': %v\n", dst, err)
-		return
+	if isSubPath(src, dst) {
+		fmt.Printf("Error: cannot copy '%s' into itself ('%s')\n", src, dst)
+		return 0, errInvalidInput
 	}
 
-	fmt.Printf("✅ Copied '%s' to '%s' (%d bytes)\n", src, dst, len(data))
-}
+	_, statErr := os.Lstat(dst)
+	existed := statErr == nil
 
-// findFiles searches for files by name in the current directory
-func findFiles(name string) {
-	// Security check
-	if !validateSearchTerm(name) {
-		return
+	var before journalSnap
+	if !info.IsDir() {
+		before = journalCapture(dst)
 	}
 
-	fmt.Printf("Searching for '%s' in current directory...\n", name)
+	var stats copyStats
+	if !isDryRun() {
+		files, bytes := treeTotals(src)
+		stats.prog = newProgress("Copying", bytes, files)
+	}
+	err = copyEntry(src, dst, info, opts, &stats)
+	stats.prog.finish()
+	if err != nil {
+		fmt.Printf("Error copying '%s' to '%s': %v\n", src, dst, err)
+		return stats.bytes, err
+	}
 
-	found := 0
-	err := filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
+	if isDryRun() {
+		return 0, nil
+	}
 
-		if strings.Contains(info.Name(), name) {
-			fmt.Printf("  📍 %s\n", path)
-			found++
+	if info.IsDir() {
+		if !existed {
+			recordJournal(journalCreate, "gxcp", dst, "", journalSnap{})
 		}
-		return nil
-	})
+		fmt.Printf("✅ Copied '%s' to '%s': %d file(s), %d dir(s), %d link(s), %d bytes",
+			src, dst, stats.files, stats.dirs, stats.links, stats.bytes)
+		if stats.skipped > 0 {
+			fmt.Printf(" (%d skipped)", stats.skipped)
+		}
+		fmt.Println()
+		return stats.bytes, nil
+	}
 
-	if err != nil {
-		fmt.Printf("Error during search: %v\n", err)
-		return
+	if stats.files == 0 {
+		fmt.Printf("⏭️  Skipped '%s' (already exists)\n", dst)
+		return 0, nil
 	}
 
-	if found == 0 {
-		fmt.Printf("No files found matching '%s'\n", name)
+	if existed {
+		recordJournal(journalModify, "gxcp", dst, "", before)
 	} else {
-		fmt.Printf("Found %d matching file(s)\n", found)
+		recordJournal(journalCreate, "gxcp", dst, "", before)
 	}
+
+	fmt.Printf("✅ Copied '%s' to '%s' (%d bytes)\n", src, dst, stats.bytes)
+	return stats.bytes, nil
 }
 
 // echoToFile appends text to a file
-func echoToFile(text, filename string) {
+func echoToFile(text, filename string) (int64, error) {
 	// Security checks
-	if !validateFilename(filename) {
-		return
+	if !validateTarget(filename) {
+		return 0, errInvalidInput
 	}
 
 	if len(text) > 10000 {
 		fmt.Println("❌ Error: Text too long (max 10000 chars)")
-		return
+		return 0, errInvalidInput
 	}
 
-	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err != nil {
-		fmt.Printf("Error opening file '%s': %v\n", filename, err)
-		return
+	if isDryRun() {
+		dryRunf("would append %d bytes to '%s'", len(text)+1, filename)
+		return 0, nil
 	}
-	defer file.Close()
 
-	_, err = file.WriteString(text + "\n")
+	// Append the whole line in a single write and flush it, so a crash never
+	// leaves a partial line and the file keeps its inode, owner and mode
+	line := text + "\n"
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		fmt.Printf("Error writing to file '%s': %v\n", filename, err)
-		return
+		return 0, err
+	}
+	_, err = file.WriteString(line)
+	if err == nil {
+		err = file.Sync()
+	}
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		fmt.Printf("Error writing to file '%s': %v\n", filename, err)
+		return 0, err
 	}
 	fmt.Printf("✅ Text written to '%s'\n", filename)
+	return int64(len(line)), nil
 }
 
 // duplicateFile creates a copy of a file with "_copy" suffix
-func duplicateFile(filename string) {
+func duplicateFile(filename string) (int64, error) {
 	// Security check
-	if !validateFilename(filename) {
-		return
+	if !validateTarget(filename) {
+		return 0, errInvalidInput
 	}
 
 	// Check file size before duplicating
 	info, err := os.Stat(filename)
 	if err != nil {
 		fmt.Printf("Error accessing file '%s': %v\n", filename, err)
-		return
+		return 0, err
 	}
 
 	if !checkFileSizeLimit(info.Size()) {
-		return
+		return 0, errInvalidInput
 	}
 
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		fmt.Printf("Error reading file '%s': %v\n", filename, err)
-		return
+		return 0, err
 	}
 
 	ext := filepath.Ext(filename)
 	base := strings.TrimSuffix(filename, ext)
 	newFilename := base + "_copy" + ext
 
-	if !validateFilename(newFilename) {
-		return
+	if !validateFilename(filepath.Base(newFilename)) {
+		return 0, errInvalidInput
+	}
+
+	if isDryRun() {
+		dryRunf("would create '%s' (%d bytes)", newFilename, len(data))
+		return 0, nil
+	}
+
+	_, statErr := os.Stat(newFilename)
+	overwrite := statErr == nil
+	if overwrite && !confirmAction("'%s' already exists. Overwrite it?", newFilename) {
+		return 0, errCancelled
 	}
 
-	err = os.WriteFile(newFilename, data, 0644)
+	before := journalCapture(newFilename)
+	err = atomicWriteFile(newFilename, data, 0644)
 	if err != nil {
 		fmt.Printf("Error creating duplicate: %v\n", err)
-		return
+		return 0, err
+	}
+	if overwrite {
+		recordJournal(journalModify, "gxdup", newFilename, "", before)
+	} else {
+		recordJournal(journalCreate, "gxdup", newFilename, "", before)
 	}
 	fmt.Printf("✅ File duplicated as '%s'\n", newFilename)
+	return int64(len(data)), nil
 }
 
 // ==================== FILE VIEWING ====================
@@ -255,99 +367,67 @@ func listItems() {
 	}
 }
 
-// viewFile displays the entire contents of a file
-func viewFile(filename string) {
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		fmt.Printf("Error reading file '%s': %v\n", filename, err)
+// viewFile displays the entire contents of a file, or the requested lines
+func viewFile(filename string, opts viewOptions) {
+	// Captured output ("gxcat a.txt | gxhash", "gxcat a.txt > b.txt") gets
+	// the bytes exactly as stored, like cat; banners, decoding and colors
+	// are only for the terminal
+	if captureStdout != nil && !opts.number && !opts.showAll && opts.from == 0 {
+		file, _ := openRegular(filename)
+		if file == nil {
+			return
+		}
+		defer file.Close()
+		if _, err := io.Copy(os.Stdout, file); err != nil {
+			fmt.Fprintf(os.Stderr, "gxcat: %s: %v\n", filename, err)
+		}
 		return
 	}
 
-	fmt.Printf("\n--- %s ---\n", filename)
-	fmt.Println(string(data))
-	if len(data) > 0 && data[len(data)-1] != '\n' {
-		fmt.Println()
-	}
-	fmt.Printf("--- End of file (%d bytes) ---\n", len(data))
-}
-
-// headFile displays the first N lines of a file
-func headFile(filename string, lines int) {
-	file, err := os.Open(filename)
-	if err != nil {
-		fmt.Printf("Error opening file '%s': %v\n", filename, err)
+	file, r, src, title, lex := openView(filename, opts.showAll)
+	if file == nil {
 		return
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	count := 0
-
-	fmt.Printf("\n--- First %d lines of %s ---\n", lines, filename)
-	for scanner.Scan() && count < lines {
-		fmt.Println(scanner.Text())
-		count++
-	}
-
-	if count == 0 {
-		fmt.Println("(file is empty)")
-	} else if count < lines {
-		fmt.Printf("--- End of file (only %d lines) ---\n", count)
-	} else {
-		fmt.Printf("--- End of head (showed %d lines) ---\n", lines)
-	}
-}
-
-// tailFile displays the last N lines of a file
-func tailFile(filename string, lines int) {
-	file, err := os.Open(filename)
-	if err != nil {
-		fmt.Printf("Error opening file '%s': %v\n", filename, err)
+	if opts.number || opts.showAll || opts.from > 0 {
+		viewLines(r, title, lex, opts)
 		return
 	}
-	defer file.Close()
-
-	var allLines []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		allLines = append(allLines, scanner.Text())
-	}
 
-	if err := scanner.Err(); err != nil {
-		fmt.Printf("Error reading file: %v\n", err)
+	// Files that do not fit on the screen open in the pager
+	if canPage() && needsPaging(src) {
+		if err := runPager(title, src, lex); err != nil {
+			fmt.Printf("Error starting pager: %v\n", err)
+		}
 		return
 	}
 
-	fmt.Printf("\n--- Last %d lines of %s ---\n", lines, filename)
-
-	start := 0
-	if len(allLines) > lines {
-		start = len(allLines) - lines
-	}
-
-	for i := start; i < len(allLines); i++ {
-		fmt.Println(allLines[i])
-	}
-
-	if len(allLines) == 0 {
-		fmt.Println("(file is empty)")
+	fmt.Printf("\n--- %s ---\n", title)
+	var n int64
+	var err error
+	endsWithNewline := false
+	if lex != nil && colorsEnabled() {
+		out := bufio.NewWriter(os.Stdout)
+		n, endsWithNewline, err = highlightCopy(out, r, lex)
+		out.Flush()
 	} else {
-		fmt.Printf("--- End of tail (showed %d of %d lines) ---\n",
-			len(allLines)-start, len(allLines))
+		tracker := &newlineTracker{w: os.Stdout}
+		n, err = io.Copy(tracker, r)
+		endsWithNewline = tracker.endsWithNewline
 	}
-}
-
-// grepFile searches for text within a file (case-insensitive)
-func grepFile(searchText, filename string) {
-	// Security checks
-	if !validateSearchTerm(searchText) {
+	if err != nil {
+		fmt.Printf("\nError reading file '%s': %v\n", filename, err)
 		return
 	}
-
-	if !validateFilename(filename) {
-		return
+	if n > 0 && !endsWithNewline {
+		fmt.Println()
 	}
+	fmt.Printf("--- End of file (%d bytes) ---\n", n)
+}
 
+// headFile displays the first lines (or bytes) of a file
+func headFile(filename string, opts viewOptions) {
 	file, err := os.Open(filename)
 	if err != nil {
 		fmt.Printf("Error opening file '%s': %v\n", filename, err)
@@ -355,24 +435,53 @@ func grepFile(searchText, filename string) {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	lineNum := 0
-	found := 0
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
 
-	fmt.Printf("\n--- Searching for '%s' in %s ---\n", searchText, filename)
-	for scanner.Scan() {
-		lineNum++
-		line := scanner.Text()
-		if strings.Contains(strings.ToLower(line), strings.ToLower(searchText)) {
-			fmt.Printf("  Line %d: %s\n", lineNum, line)
-			found++
+	// Byte counts refer to the file as stored; lines are decoded to UTF-8
+	if opts.bytes >= 0 {
+		fmt.Fprintf(out, "\n--- First %d bytes of %s ---\n", opts.bytes, filename)
+		limited := &io.LimitedReader{R: file, N: opts.bytes}
+		tracker, err := copyView(out, limited, opts.showAll)
+		if err != nil {
+			fmt.Fprintf(out, "Error reading file: %v\n", err)
+			return
+		}
+		if tracker.written > 0 && !tracker.endsWithNewline {
+			fmt.Fprintln(out)
 		}
+		fmt.Fprintf(out, "--- End of head (%d bytes) ---\n", opts.bytes-limited.N)
+		return
+	}
+
+	enc, _ := sniffText(file)
+	if enc != encUTF8 {
+		filename = fmt.Sprintf("%s [%s]", filename, enc)
+	}
+	count := 0
+	fmt.Fprintf(out, "\n--- First %d lines of %s ---\n", opts.lines, filename)
+	err = forEachLine(newDecodeReader(file, enc), func(line, eol string) error {
+		if count >= opts.lines {
+			return errStopReading
+		}
+		count++
+		if opts.showAll {
+			line = showNonPrinting(line, eol)
+		}
+		fmt.Fprintln(out, strings.TrimSuffix(line, "\n"))
+		return nil
+	})
+	if err != nil && err != errStopReading {
+		fmt.Fprintf(out, "Error reading file: %v\n", err)
+		return
 	}
 
-	if found == 0 {
-		fmt.Printf("No matches found for '%s'\n", searchText)
+	if count == 0 {
+		fmt.Fprintln(out, "(file is empty)")
+	} else if count < opts.lines {
+		fmt.Fprintf(out, "--- End of file (only %d lines) ---\n", count)
 	} else {
-		fmt.Printf("--- Found %d match(es) ---\n", found)
+		fmt.Fprintf(out, "--- End of head (showed %d lines) ---\n", opts.lines)
 	}
 }
 
@@ -385,18 +494,9 @@ func showSize(name string) {
 		return
 	}
 
-	var totalSize int64
-
-	err := filepath.Walk(name, func(_ string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() {
-			totalSize += info.Size()
-		}
-		return nil
-	})
-
+	prog := newProgress("Measuring", 0, 0)
+	totalSize, err := walkSize(name, prog)
+	prog.finish()
 	if err != nil {
 		fmt.Println("Error calculating size:", err)
 		return
@@ -412,6 +512,29 @@ func showSize(name string) {
 	}
 }
 
+// dirSize returns the total size of a file or of all files under a directory
+func dirSize(name string) (int64, error) {
+	return walkSize(name, nil)
+}
+
+// walkSize totals file sizes under name, reporting each file to prog
+func walkSize(name string, prog *progress) (int64, error) {
+	var totalSize int64
+
+	err := filepath.Walk(name, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			totalSize += info.Size()
+			prog.addBytes(info.Size())
+			prog.addFile()
+		}
+		return nil
+	})
+	return totalSize, err
+}
+
 // printWorkingDir displays the current working directory
 func printWorkingDir() {
 	dir, err := os.Getwd()
@@ -522,40 +645,64 @@ func countFiles(path string) {
 }
 
 // createEmptyFile creates an empty file
-func createEmptyFile(name string) {
+func createEmptyFile(name string) (int64, error) {
 	// Security check
 	if !validateFilename(name) {
-		return
+		return 0, errInvalidInput
+	}
+
+	if isDryRun() {
+		dryRunf("would create empty file '%s'", name)
+		return 0, nil
+	}
+
+	if info, err := os.Stat(name); err == nil && info.Size() > 0 &&
+		!confirmAction("'%s' already exists (%d bytes). Replace it with an empty file?", name, info.Size()) {
+		return 0, errCancelled
 	}
 
+	before := journalCapture(name)
 	file, err := os.Create(name)
 	if err != nil {
 		fmt.Printf("Error creating file '%s': %v\n", name, err)
-		return
+		return 0, err
 	}
 	file.Close()
+	if before.State.Exists {
+		recordJournal(journalModify, "gxempty", name, "", before)
+	} else {
+		recordJournal(journalCreate, "gxempty", name, "", before)
+	}
 	fmt.Printf("📄 Empty file '%s' created (0 bytes)\n", name)
+	return 0, nil
 }
 
 // createDirectory creates a new directory
-func createDirectory(name string) {
+func createDirectory(name string) (int64, error) {
 	// Security check
 	if !validateFilename(name) {
-		return
+		return 0, errInvalidInput
+	}
+
+	if isDryRun() {
+		dryRunf("would create directory '%s'", name)
+		return 0, nil
 	}
 
 	err := os.Mkdir(name, 0755)
 	if err != nil {
 		fmt.Printf("Error creating directory '%s': %v\n", name, err)
-		return
+		return 0, err
 	}
+	recordJournal(journalCreate, "gxmkdir", name, "", journalSnap{})
 	fmt.Printf("📁 Directory '%s' created\n", name)
+	return 0, nil
 }
 
 // showFileStats displays detailed statistics about a file
 func showFileStats(filename string) {
 	// Security check
-	if !validateFilename(filename) {
+	if !validateTarget(filename) {
 		return
 	}
 
@@ -585,81 +732,46 @@ func showFileStats(filename string) {
 }
 
 // touchFile creates or updates the timestamp of a file
-func touchFile(filename string) {
+func touchFile(filename string) (int64, error) {
 	// Security check
-	if !validateFilename(filename) {
-		return
+	if !validateTarget(filename) {
+		return 0, errInvalidInput
+	}
+
+	if isDryRun() {
+		if _, err := os.Stat(filename); os.IsNotExist(err) {
+			dryRunf("would create empty file '%s'", filename)
+		} else {
+			dryRunf("would update the timestamp of '%s'", filename)
+		}
+		return 0, nil
 	}
 
 	if _, err := os.Stat(filename); os.IsNotExist(err) {
 		file, err := os.Create(filename)
 		if err != nil {
 			fmt.Printf("Error creating file '%s': %v\n", filename, err)
-			return
+			return 0, err
 		}
 		file.Close()
+		recordJournal(journalCreate, "gxtouch", filename, "", journalSnap{})
 		fmt.Printf("✅ File '%s' created (touched)\n", filename)
-		return
+		return 0, nil
 	}
 
 	now := time.Now()
 	err := os.Chtimes(filename, now, now)
 	if err != nil {
 		fmt.Printf("Error touching file '%s': %v\n", filename, err)
-		return
+		return 0, err
 	}
 	fmt.Printf("✅ File '%s' timestamp updated\n", filename)
-}
-
-// gxmd5 computes and prints the MD5 checksum of a file
-func gxmd5(filename string) {
-	if !validateFilename(filename) {
-		return
-	}
-
-	file, err := os.Open(filename)
-	if err != nil {
-		fmt.Printf("Error opening file '%s': %v\n", filename, err)
-		return
-	}
-	defer file.Close()
-
-	hasher := md5.New()
-	if _, err := io.Copy(hasher, file); err != nil {
-		fmt.Printf("Error reading file '%s': %v\n", filename, err)
-		return
-	}
-
-	sum := hasher.Sum(nil)
-	fmt.Printf("MD5(%s) = %x\n", filename, sum)
-}
-
-// gxsha1 computes and prints the SHA-1 checksum of a file
-func gxsha1(filename string) {
-	if !validateFilename(filename) {
-		return
-	}
-
-	file, err := os.Open(filename)
-	if err != nil {
-		fmt.Printf("Error opening file '%s': %v\n", filename, err)
-		return
-	}
-	defer file.Close()
-
-	hasher := sha1.New()
-	if _, err := io.Copy(hasher, file); err != nil {
-		fmt.Printf("Error reading file '%s': %v\n", filename, err)
-		return
-	}
-
-	sum := hasher.Sum(nil)
-	fmt.Printf("SHA1(%s) = %x\n", filename, sum)
+	return 0, nil
 }
 
 // gxcountwords counts words in a file and prints the total
 func gxcountwords(filename string) {
-	if !validateFilename(filename) {
+	if !validateTarget(filename) {
 		return
 	}
 
@@ -686,28 +798,68 @@ func gxcountwords(filename string) {
 }
 
 // gxtruncate truncates a file to the given size in bytes
-func gxtruncate(filename, sizeStr string) {
-	if !validateFilename(filename) {
-		return
+func gxtruncate(filename, sizeStr string) (int64, error) {
+	if !validateTarget(filename) {
+		return 0, errInvalidInput
 	}
 
 	size, err := strconv.ParseInt(sizeStr, 10, 64)
 	if err != nil {
 		fmt.Printf("Invalid size '%s': %v\n", sizeStr, err)
-		return
+		return 0, err
+	}
+	if size < 0 {
+		fmt.Printf("Invalid size '%s': must not be negative\n", sizeStr)
+		return 0, errInvalidInput
 	}
 
-	if err := os.Truncate(filename, size); err != nil {
+	info, err := os.Stat(filename)
+	if err != nil {
 		fmt.Printf("Error truncating '%s': %v\n", filename, err)
-		return
+		return 0, err
 	}
 
+	if isDryRun() {
+		dryRunf("would truncate '%s' from %d to %d bytes", filename, info.Size(), size)
+		return 0, nil
+	}
+
+	if size < info.Size() && !confirmAction("Truncate '%s' from %d to %d bytes? %d bytes will be lost.", filename, info.Size(), size, info.Size()-size) {
+		return 0, errCancelled
+	}
+
+	before := journalCapture(filename)
+	if size >= info.Size() {
+		// Growing loses nothing and leaves a sparse hole, so a plain truncate is safe
+		err = os.Truncate(filename, size)
+	} else {
+		err = atomicWrite(filename, info.Mode(), func(w io.Writer) error {
+			file, err := os.Open(filename)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			_, err = io.CopyN(w, file, size)
+			return err
+		})
+	}
+	if err != nil {
+		fmt.Printf("Error truncating '%s': %v\n", filename, err)
+		return 0, err
+	}
+	recordJournal(journalModify, "gxtruncate", filename, "", before)
+
 	fmt.Printf("✅ Truncated '%s' to %d bytes\n", filename, size)
+	changed := info.Size() - size
+	if changed < 0 {
+		changed = -changed
+	}
+	return changed, nil
 }
 
 // gxpermissions shows file permission bits and basic metadata
 func gxpermissions(filename string) {
-	if !validateFilename(filename) {
+	if !validateTarget(filename) {
 		return
 	}
 
@@ -725,7 +877,7 @@ func gxpermissions(filename string) {
 
 // gxemptylinecount counts empty (blank) lines in a file
 func gxemptylinecount(filename string) {
-	if !validateFilename(filename) {
+	if !validateTarget(filename) {
 		return
 	}
 
@@ -754,7 +906,7 @@ func gxemptylinecount(filename string) {
 
 // gxlines counts and prints the number of lines in a file
 func gxlines(filename string) {
-	if !validateFilename(filename) {
+	if !validateTarget(filename) {
 		return
 	}
 
@@ -779,32 +931,9 @@ func gxlines(filename string) {
 	fmt.Printf("%s: %d lines\n", filename, lines)
 }
 
-// gxreplace replaces all occurrences of old with new in the provided file
-func gxreplace(old, new, filename string) {
-	if !validateFilename(filename) {
-		return
-	}
-
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		fmt.Printf("Error reading file '%s': %v\n", filename, err)
-		return
-	}
-
-	content := strings.ReplaceAll(string(data), old, new)
-
-	err = os.WriteFile(filename, []byte(content), 0644)
-	if err != nil {
-		fmt.Printf("Error writing file '%s': %v\n", filename, err)
-		return
-	}
-
-	fmt.Printf("✅ Replaced '%s' with '%s' in '%s'\n", old, new, filename)
-}
-
 // gxopen opens a file with the system default application
 func gxopen(filename string) {
-	if !validateFilename(filename) {
+	if !validateTarget(filename) {
 		return
 	}
 
@@ -826,9 +955,9 @@ func gxopen(filename string) {
 }
 
 // gxrenameext renames a file's extension to the provided new extension (without dot or with dot)
-func gxrenameext(filename, newext string) {
-	if !validateFilename(filename) {
-		return
+func gxrenameext(filename, newext string) (int64, error) {
+	if !validateTarget(filename) {
+		return 0, errInvalidInput
 	}
 
 	if !strings.HasPrefix(newext, ".") {
@@ -840,38 +969,59 @@ func gxrenameext(filename, newext string) {
 	newname := base + newext
 
 	if !validateFilename(filepath.Base(newname)) {
-		return
+		return 0, errInvalidInput
 	}
 
+	size, _ := dirSize(filename)
+
+	if isDryRun() {
+		dryRunf("would rename '%s' to '%s'", filename, newname)
+		return 0, nil
+	}
+
+	if _, err := os.Stat(newname); err == nil && !confirmAction("'%s' already exists. Overwrite it?", newname) {
+		return 0, errCancelled
+	}
+
+	before := journalCapture(newname)
 	if err := os.Rename(filename, newname); err != nil {
 		fmt.Printf("Error renaming '%s' to '%s': %v\n", filename, newname, err)
-		return
+		return 0, err
 	}
+	recordJournal(journalMove, "gxrenameext", newname, filename, before)
 
 	fmt.Printf("✅ Renamed '%s' -> '%s'\n", filename, newname)
+	return size, nil
 }
 
 // gxbackup creates a timestamped backup copy of a file
-func gxbackup(filename string) {
-	if !validateFilename(filename) {
-		return
+func gxbackup(filename string) (int64, error) {
+	if !validateTarget(filename) {
+		return 0, errInvalidInput
 	}
 
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		fmt.Printf("Error reading file '%s': %v\n", filename, err)
-		return
+		return 0, err
 	}
 
 	ts := time.Now().Format("20060102T150405")
 	backupName := filename + ".bak." + ts
 
-	if err := os.WriteFile(backupName, data, 0644); err != nil {
+	if isDryRun() {
+		dryRunf("would create backup '%s' (%d bytes)", backupName, len(data))
+		return 0, nil
+	}
+
+	if err := atomicWriteFile(backupName, data, 0644); err != nil {
 		fmt.Printf("Error creating backup '%s': %v\n", backupName, err)
-		return
+		return 0, err
 	}
+	recordJournal(journalCreate, "gxbackup", backupName, "", journalSnap{})
 
 	fmt.Printf("✅ Backup created: %s\n", backupName)
+	return int64(len(data)), nil
 }
 
 // ==================== HELP ====================
@@ -885,22 +1035,76 @@ func showExtendedHelp() {
 
 📁 FILE OPERATIONS:
   gx [name]         - Create file (with .) or folder without extension
-  gxd [name]        - Delete file or folder recursively
+  gxd [name]        - Move file or folder to the trash
+  gxd --force [name] - Delete permanently (recursive)
   gxc [path]        - Change directory
   gxl               - List files in current directory
   gxs [name]        - Show total size of file/folder
-  gxmv [src] [dst]  - Move or rename a file/folder
-  gxcp [src] [dst]  - Copy a file
+  gxmv [src] [dst]  - Move or rename a file/folder (copies and verifies across filesystems)
+  gxcp [src] [dst]  - Copy a file (keeps mode and modification time)
+  gxcp -r [src] [dst] - Copy a directory tree (symlinks are preserved)
+      --overwrite never|always|if-newer - How to treat existing files
   gxfind [name]     - Search for files containing name
+  gxfind [paths] [expr] - Find with predicates, combined with -a, -o, ! and ( )
+      -type f|d|l, -name/-iname glob, -regex re, -size +10M, -mtime -2d,
+      -empty, -maxdepth N, -mindepth N
+      actions: -print, -delete (to trash), -exec gxcmd {} ;
+  gxfzf [pattern]   - Fuzzy-find files, best matches first (-n N for more)
+      --dirs include directories, --hidden, --no-ignore
+      --cat pick a result and view it, --cd pick a result and change to it
+  gxindex build [dir] - Index a tree so gxfind and gxfzf answer without walking it
+  gxindex refresh|status|remove - Update (re-reads changed directories only), show or drop it
   gxecho [text] [file] - Append text to file
   gxdup [file]      - Create a duplicate copy of file
 
 📖 FILE VIEWING:
-  gxcat [file]      - Display entire file contents
+  gxcat [file]      - Display entire file contents (opens the pager when it does not fit)
+      Go, JSON, YAML, Markdown and shell files are syntax highlighted on a terminal
+      -n                number lines
+      -A                show tabs (^I), control characters, invalid UTF-8 (\xHH),
+                        invisible Unicode (<U+FEFF>) and line ends ($)
+      file:120-180      show only lines 120-180 (file:120- to the end, file:120 one line)
+  gxhex [file]      - Hex dump with offset, hex bytes and ASCII columns
+      -s OFFSET         start at OFFSET (decimal or 0x hex; negative counts from the end)
+      -n LENGTH         show LENGTH bytes
+      -v                show repeated rows instead of collapsing them to '*'
+      -d file1 file2    compare two files byte by byte, showing only differing rows
+  gxiconv [files]   - Report each file's encoding and line endings
+      -t ENC            convert to utf-8, utf-8-bom, utf-16le, utf-16be, latin1 or windows-1252
+      -f ENC            source encoding (detected when omitted)
+      --lf / --crlf     normalize line endings
+  gxless [file]     - View a file in the pager; [command] | gxless pages command output
+      pager keys: space/b page, j/k line, g/G top/end, /re search, n/N next/prev,
+      :N jump to line, # line numbers, ←/→ scroll sideways, q quit
   gxhead [file]     - Show first 10 lines
-  gxtail [file]     - Show last 10 lines
-  gxgrep [text] [file] - Find lines containing text
+  gxtail [file]     - Show last 10 lines (reads backwards, any file size)
+      -n N (or -N)      number of lines to show
+      -c BYTES          show bytes instead of lines
+      -A                show non-printing characters as gxcat -A does
+      -f                (gxtail) keep following the files as they grow; survives
+                        rotation and truncation, Ctrl+C stops
+  gxgrep [opts] [text] [files...] - Find lines containing text
+      -e regex, -s case-sensitive, -w word, -v invert, -c count, -l names only
+      -A/-B/-C N context lines, -r recurse into directories, --color/--no-color
+      -r skips binary, hidden and .gitignore'd files (--hidden, --no-ignore), -j N workers
   gxstat [file]     - Show detailed file statistics
+  gxhash [files]    - SHA-256 checksums in sha256sum format (files and globs)
+      -a ALG            md5, sha1, sha256, sha512, blake2b or crc32
+      -j N              hash N files at a time (default: one per CPU)
+      --tag             print "SHA256 (file) = ..." lines
+      -r                hash every file under directories, e.g. gxhash -r dist > SHA256SUMS
+      [command] | gxhash  hash a command's output, e.g. gxcat a.txt | gxhash
+                        (gxcat passes the file's bytes through unchanged)
+  gxverify [sums]   - Check files against a checksum file: OK, FAILED or MISSING
+      -q only list problems, -a ALG for untagged 128-digit sums (default sha512)
+  gxdupes [dirs]    - Find sets of identical files and the space they waste
+      --link            replace copies with hard links to the oldest file
+      --trash           move copies to the trash (undo with gxundo)
+  gxfingerprint [dirs] - Hash a whole tree (names, modes, contents); two dirs: identical?
+      --save FILE       also save a snapshot for gxchanged
+  gxchanged [snapshot] [dir] - List files added (+), removed (-) or modified (M) since a snapshot
+  gxmd5 [file]      - Show MD5 checksum (gxhash -a md5 --tag)
+  gxsha1 [file]     - Show SHA-1 checksum (gxhash -a sha1 --tag)
 
 🖥️  SYSTEM INFO:
   gxpwd             - Print current working directory
@@ -914,8 +1118,40 @@ func showExtendedHelp() {
   gxempty [file]    - Create empty file
   gxmkdir [dir]     - Create directory
   gxtouch [file]    - Create/update file timestamp
+  gxreplace [-e] [-i] [-w] [old] [new] [files/globs...]
+                    - Replace text in files (-e regex with $1 groups, -i ignore
+                      case, -w whole words); previews the diff before applying
+  gxtruncate [file] [bytes]    - Truncate file to size
+  gxrenameext [file] [ext]     - Change file extension
+  gxbackup [file]   - Create timestamped backup copy
+
+♻️  TRASH:
+  gxtrash list      - Show trashed items with their IDs
+  gxtrash restore [id]        - Restore an item to its original path
+  gxtrash empty [--older-than 7d] - Permanently delete trashed items
+
+🔐 AUDIT:
+  gxaudit [-n N] [-c cmd] [-u user] [--since YYYY-MM-DD] [--failed]
+                    - Show recent file-modifying commands
+  gxaudit verify    - Check the audit log hash chain for tampering
+                      (unkeyed: detects edits, not a writer who recomputes the chain)
   gxhelp            - Show this help message
 
+↩️  UNDO:
+  gxundo [--force]  - Undo the last create/move/copy/replace/truncate/rename/delete
+  gxredo [--force]  - Redo the last undone operation
+  gxjournal         - List this session's undoable operations
+
+⚠️  SAFETY:
+  gxd, gxtruncate, gxreplace and overwriting moves/copies ask for confirmation.
+  [command] -y      - Skip the confirmation prompt (before any file name)
+  [command] --dry-run - Show what would change without touching any file
+  gxset dry-run on|off - Preview every command for the rest of the session
+  gxset progress auto|on|off|json - How long operations report progress
+  gxset confirm on|off - Enable or disable confirmation prompts
+  gxset pager on|off - Open long gxcat output in the pager
+  gxset theme default|light|mono|none - Colors for highlighting and search matches
+
 ⏹️  CONTROL:
   exit or Ctrl+X    - Exit the shell
 
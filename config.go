@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// configDirPath returns the location of the GX-Shell configuration directory
+// without creating it. GX_CONFIG_DIR overrides the default location under the
+// user config directory.
+func configDirPath() (string, error) {
+	if dir := os.Getenv("GX_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locating config directory: %w", err)
+	}
+	return filepath.Join(base, "gx-shell"), nil
+}
+
+// configDir returns the GX-Shell configuration directory, creating it if needed
+func configDir() (string, error) {
+	dir, err := configDirPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("creating config directory: %w", err)
+	}
+	return dir, nil
+}
+
+// configPath returns the path of a file inside the configuration directory
+func configPath(name string) (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
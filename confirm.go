@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ==================== CONFIRMATION & DRY-RUN ====================
+
+// shellOptions controls how destructive commands behave
+type shellOptions struct {
+	dryRun    bool // report changes without touching the filesystem
+	assumeYes bool // skip confirmation prompts
+}
+
+// session holds the options set for the whole shell session (startup flags or gxset);
+// current holds the options given to the command being executed.
+var (
+	session shellOptions
+	current shellOptions
+)
+
+// extractGlobalFlags removes -y/--yes and --dry-run from the options right
+// after the command name and returns the remaining parts together with the
+// options they selected. Scanning stops at the first argument that is not
+// an option, or at "--", so a file named "-y" or a pattern "--dry-run" is
+// passed through untouched.
+func extractGlobalFlags(parts []string) ([]string, shellOptions) {
+	var opts shellOptions
+	kept := make([]string, 0, len(parts))
+	leading := true
+	for i, part := range parts {
+		if i > 0 && leading {
+			switch {
+			case part == "-y" || part == "--yes":
+				opts.assumeYes = true
+				continue
+			case part == "--dry-run":
+				opts.dryRun = true
+				continue
+			case part == "--" || !strings.HasPrefix(part, "-"):
+				leading = false
+			}
+		}
+		kept = append(kept, part)
+	}
+	return kept, opts
+}
+
+// isDryRun reports whether mutating commands should only describe their changes
+func isDryRun() bool {
+	return session.dryRun || current.dryRun
+}
+
+// skipConfirm reports whether confirmation prompts are disabled
+func skipConfirm() bool {
+	return session.assumeYes || current.assumeYes
+}
+
+// dryRunf prints a description of a change that dry-run mode skipped
+func dryRunf(format string, args ...interface{}) {
+	activeProgress.pause()
+	fmt.Printf("🔎 [dry-run] "+format+"\n", args...)
+}
+
+// confirmAction asks the user to approve a destructive operation.
+// It returns true without asking when -y or the session policy allows it.
+func confirmAction(format string, args ...interface{}) bool {
+	if skipConfirm() {
+		return true
+	}
+
+	activeProgress.pause()
+	fmt.Fprintf(promptOut(), "⚠️  "+format+" [y/N]: ", args...)
+	if !inputScanner.Scan() {
+		fmt.Fprintln(promptOut())
+		return false
+	}
+
+	answer := strings.ToLower(strings.TrimSpace(inputScanner.Text()))
+	if answer == "y" || answer == "yes" {
+		return true
+	}
+	fmt.Fprintln(promptOut(), "Cancelled.")
+	return false
+}
+
+// errCancelled is returned by handlers when the user declines a confirmation
+var errCancelled = errors.New("cancelled by user")
+
+// gxset shows or changes session options
+func gxset(args []string) {
+	const usage = "Usage: gxset [dry-run|confirm|pager] [on|off] | gxset progress [auto|on|off|json] | gxset theme [name]"
+
+	if len(args) == 0 {
+		fmt.Printf("dry-run: %s\n", onOff(session.dryRun))
+		fmt.Printf("confirm: %s\n", onOff(!session.assumeYes))
+		fmt.Printf("progress: %s\n", progressMode)
+		fmt.Printf("pager: %s\n", onOff(pagerEnabled))
+		fmt.Printf("theme: %s (available: %s)\n", themeName, strings.Join(themeNames(), ", "))
+		return
+	}
+
+	if len(args) < 2 {
+		fmt.Println(usage)
+		return
+	}
+
+	value := args[1]
+	switch args[0] {
+	case "dry-run", "confirm", "pager":
+		if value != "on" && value != "off" {
+			fmt.Println(usage)
+			return
+		}
+		switch args[0] {
+		case "dry-run":
+			session.dryRun = value == "on"
+		case "confirm":
+			session.assumeYes = value == "off"
+		case "pager":
+			pagerEnabled = value == "on"
+		}
+	case "theme":
+		if themes[value] == nil {
+			fmt.Printf("Unknown theme: %s (available: %s)\n", value, strings.Join(themeNames(), ", "))
+			return
+		}
+		themeName = value
+	case "progress":
+		switch value {
+		case progressAuto, progressOn, progressOff, progressJSON:
+			progressMode = value
+		default:
+			fmt.Println(usage)
+			return
+		}
+	default:
+		fmt.Printf("Unknown option: %s\n", args[0])
+		fmt.Println(usage)
+		return
+	}
+	fmt.Printf("✅ %s is now %s\n", args[0], value)
+}
+
+// onOff formats a boolean setting
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractGlobalFlags(t *testing.T) {
+	tests := []struct {
+		parts []string
+		want  []string
+		opts  shellOptions
+	}{
+		{[]string{"gxd", "-y", "old"}, []string{"gxd", "old"}, shellOptions{assumeYes: true}},
+		{[]string{"gxd", "--dry-run", "--yes", "old"}, []string{"gxd", "old"}, shellOptions{dryRun: true, assumeYes: true}},
+		{[]string{"gxdupes", "--link", "-y"}, []string{"gxdupes", "--link"}, shellOptions{assumeYes: true}},
+		{[]string{"gxecho", "note", "-y"}, []string{"gxecho", "note", "-y"}, shellOptions{}},
+		{[]string{"gxgrep", "--", "-y", "notes.txt"}, []string{"gxgrep", "--", "-y", "notes.txt"}, shellOptions{}},
+		{[]string{"gxreplace", "a", "--dry-run", "f"}, []string{"gxreplace", "a", "--dry-run", "f"}, shellOptions{}},
+		{[]string{"-y"}, []string{"-y"}, shellOptions{}},
+	}
+	for _, tt := range tests {
+		got, opts := extractGlobalFlags(tt.parts)
+		if !reflect.DeepEqual(got, tt.want) || opts != tt.opts {
+			t.Errorf("extractGlobalFlags(%q) = %q, %+v; want %q, %+v", tt.parts, got, opts, tt.want, tt.opts)
+		}
+	}
+}
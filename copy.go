@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ==================== COPYING ====================
+
+// Overwrite policies for gxcp
+const (
+	overwriteAsk     = "ask"      // confirm each existing destination (default)
+	overwriteNever   = "never"    // keep existing destinations
+	overwriteAlways  = "always"   // replace existing destinations
+	overwriteIfNewer = "if-newer" // replace only when the source is newer
+)
+
+// copyOptions controls how gxcp copies files and trees
+type copyOptions struct {
+	recursive bool
+	overwrite string
+}
+
+// copyStats counts what a copy did
+type copyStats struct {
+	files   int
+	dirs    int
+	links   int
+	skipped int
+	bytes   int64
+	prog    *progress
+}
+
+// parseCopyArgs parses "gxcp [-r] [--overwrite policy] src dst"
+func parseCopyArgs(args []string) (copyOptions, []string, error) {
+	opts := copyOptions{overwrite: overwriteAsk}
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-r" || arg == "-R" || arg == "--recursive":
+			opts.recursive = true
+		case arg == "--overwrite" || strings.HasPrefix(arg, "--overwrite="):
+			value := strings.TrimPrefix(arg, "--overwrite=")
+			if arg == "--overwrite" {
+				if i+1 >= len(args) {
+					return opts, nil, fmt.Errorf("missing value for --overwrite")
+				}
+				i++
+				value = args[i]
+			}
+			switch value {
+			case overwriteAsk, overwriteNever, overwriteAlways, overwriteIfNewer:
+				opts.overwrite = value
+			default:
+				return opts, nil, fmt.Errorf("unknown overwrite policy '%s' (use never, always or if-newer)", value)
+			}
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return opts, rest, nil
+}
+
+// shouldOverwrite applies the overwrite policy to an existing destination
+func shouldOverwrite(policy string, srcInfo, dstInfo os.FileInfo, dst string) bool {
+	switch policy {
+	case overwriteNever:
+		return false
+	case overwriteAlways:
+		return true
+	case overwriteIfNewer:
+		return srcInfo.ModTime().After(dstInfo.ModTime())
+	}
+	if isDryRun() {
+		return true
+	}
+	return confirmAction("'%s' already exists. Overwrite it?", dst)
+}
+
+// streamCopyFile atomically copies a regular file with io.Copy and preserves
+// its mode and mtime
+func streamCopyFile(src, dst string, info os.FileInfo, prog *progress) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	var n int64
+	err = atomicWrite(dst, info.Mode(), func(w io.Writer) error {
+		var err error
+		n, err = io.Copy(w, prog.reader(in))
+		return err
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, preserveMetadata(dst, info)
+}
+
+// preserveMetadata applies the source mode and modification time to dst
+func preserveMetadata(dst string, info os.FileInfo) error {
+	if err := os.Chmod(dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+// copySymlink recreates a symbolic link at dst
+func copySymlink(src, dst string) error {
+	target, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		if err := os.Remove(dst); err != nil {
+			return err
+		}
+	}
+	return os.Symlink(target, dst)
+}
+
+// copyEntry copies a single file, symlink or directory tree from src to dst
+func copyEntry(src, dst string, info os.FileInfo, opts copyOptions, stats *copyStats) error {
+	if info.Mode()&os.ModeSymlink != 0 {
+		if dstInfo, err := os.Lstat(dst); err == nil && !shouldOverwrite(opts.overwrite, info, dstInfo, dst) {
+			stats.skipped++
+			return nil
+		}
+		if isDryRun() {
+			dryRunf("would link '%s' -> '%s'", dst, src)
+			stats.links++
+			return nil
+		}
+		if err := copySymlink(src, dst); err != nil {
+			return err
+		}
+		stats.links++
+		return nil
+	}
+
+	if info.IsDir() {
+		return copyDir(src, dst, info, opts, stats)
+	}
+
+	if !info.Mode().IsRegular() {
+		stats.prog.printf("⚠️  Skipping special file '%s'\n", src)
+		stats.skipped++
+		return nil
+	}
+
+	if dstInfo, err := os.Stat(dst); err == nil {
+		if dstInfo.IsDir() {
+			return fmt.Errorf("cannot overwrite directory '%s' with a file", dst)
+		}
+		if !shouldOverwrite(opts.overwrite, info, dstInfo, dst) {
+			stats.skipped++
+			stats.prog.addBytes(info.Size())
+			stats.prog.addFile()
+			return nil
+		}
+	}
+
+	if isDryRun() {
+		dryRunf("would copy '%s' to '%s' (%d bytes)", src, dst, info.Size())
+		stats.files++
+		stats.bytes += info.Size()
+		return nil
+	}
+
+	n, err := streamCopyFile(src, dst, info, stats.prog)
+	stats.bytes += n
+	if err != nil {
+		return err
+	}
+	stats.files++
+	stats.prog.addFile()
+	return nil
+}
+
+// copyDir recursively copies a directory, preserving mode and mtime
+func copyDir(src, dst string, info os.FileInfo, opts copyOptions, stats *copyStats) error {
+	if dstInfo, err := os.Stat(dst); err == nil {
+		if !dstInfo.IsDir() {
+			return fmt.Errorf("cannot overwrite file '%s' with a directory", dst)
+		}
+	} else if isDryRun() {
+		dryRunf("would create directory '%s'", dst)
+	} else if err := os.Mkdir(dst, info.Mode().Perm()|0700); err != nil {
+		return err
+	}
+	stats.dirs++
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		childInfo, err := os.Lstat(filepath.Join(src, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if err := copyEntry(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name()), childInfo, opts, stats); err != nil {
+			return err
+		}
+	}
+
+	if isDryRun() {
+		return nil
+	}
+	return preserveMetadata(dst, info)
+}
+
+// treeTotals counts the regular files and bytes under a path
+func treeTotals(path string) (int64, int64) {
+	var files, bytes int64
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			files++
+			bytes += info.Size()
+		}
+		return nil
+	})
+	return files, bytes
+}
+
+// isSubPath reports whether child is inside (or equal to) parent
+func isSubPath(parent, child string) bool {
+	parentAbs, err1 := filepath.Abs(parent)
+	childAbs, err2 := filepath.Abs(child)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	rel, err := filepath.Rel(parentAbs, childAbs)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
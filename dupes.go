@@ -0,0 +1,390 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+)
+
+// ==================== DUPLICATE FILES ====================
+
+// dupesPartialSize is how much of each file is hashed before deciding
+// whether files of the same size are worth hashing in full
+const dupesPartialSize = 4096
+
+// dupesOptions holds the parsed gxdupes arguments
+type dupesOptions struct {
+	link  bool // replace duplicates with hard links to the kept copy
+	trash bool // move duplicates to the trash
+	jobs  int
+	dirs  []string
+}
+
+// parseDupesArgs parses "gxdupes [--link|--trash] [-j N] [dir...]"
+func parseDupesArgs(args []string) (dupesOptions, error) {
+	opts := dupesOptions{jobs: runtime.NumCPU()}
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--link":
+			opts.link = true
+		case "--trash":
+			opts.trash = true
+		case "-j":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("missing value for -j")
+			}
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				return opts, fmt.Errorf("invalid job count '%s'", args[i])
+			}
+			opts.jobs = n
+		default:
+			if len(arg) > 1 && arg[0] == '-' {
+				return opts, fmt.Errorf("unknown option %s", arg)
+			}
+			opts.dirs = append(opts.dirs, arg)
+		}
+	}
+	if opts.link && opts.trash {
+		return opts, fmt.Errorf("choose either --link or --trash")
+	}
+	if len(opts.dirs) == 0 {
+		opts.dirs = []string{"."}
+	}
+	return opts, nil
+}
+
+// dupeFile is a candidate file found by the scan
+type dupeFile struct {
+	path string
+	info os.FileInfo
+}
+
+// dupeSet is a group of files with identical contents; files[0] is kept
+type dupeSet struct {
+	size  int64
+	files []dupeFile
+}
+
+// wasted is the space used by the redundant copies
+func (s dupeSet) wasted() int64 {
+	return s.size * int64(len(s.files)-1)
+}
+
+// scanDupeCandidates walks the directories and groups non-empty regular
+// files by size. Files already hard-linked together count once, and the
+// trash and gx's own configuration (journal stashes) are skipped.
+func scanDupeCandidates(dirs []string) (map[int64][]dupeFile, int, error) {
+	skip := make(map[string]bool)
+	for _, locate := range []func() (string, error){trashPath, configDirPath} {
+		if dir, err := locate(); err == nil {
+			abs, _ := filepath.Abs(dir)
+			skip[abs] = true
+		}
+	}
+	bySize := make(map[int64][]dupeFile)
+	scanned := 0
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if abs, _ := filepath.Abs(path); skip[abs] {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil || info.Size() == 0 {
+				return err
+			}
+			scanned++
+			for _, seen := range bySize[info.Size()] {
+				if os.SameFile(seen.info, info) {
+					return nil
+				}
+			}
+			bySize[info.Size()] = append(bySize[info.Size()], dupeFile{path, info})
+			return nil
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return bySize, scanned, nil
+}
+
+// partialHash hashes the first dupesPartialSize bytes of a file
+func partialHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return hashReader(io.LimitReader(file, dupesPartialSize), "sha256")
+}
+
+// groupByHash splits files into groups with the same hash, dropping files
+// that could not be read and groups with a single file
+func groupByHash(files []dupeFile, jobs int, hash func(path string) (string, error)) [][]dupeFile {
+	results := hashParallel(len(files), jobs, func(i int) (string, error) {
+		return hash(files[i].path)
+	})
+	byHash := make(map[string][]dupeFile)
+	var order []string
+	for i, file := range files {
+		res := <-results[i]
+		if res.err != nil {
+			fmt.Fprintf(os.Stderr, "gxdupes: %s: %v\n", file.path, res.err)
+			continue
+		}
+		if byHash[res.sum] == nil {
+			order = append(order, res.sum)
+		}
+		byHash[res.sum] = append(byHash[res.sum], file)
+	}
+	var groups [][]dupeFile
+	for _, sum := range order {
+		if len(byHash[sum]) > 1 {
+			groups = append(groups, byHash[sum])
+		}
+	}
+	return groups
+}
+
+// findDupes narrows files down by size, then by the hash of their first
+// bytes, then by a full hash, so most files are never read in full
+func findDupes(opts dupesOptions) ([]dupeSet, int, error) {
+	bySize, scanned, err := scanDupeCandidates(opts.dirs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var candidates [][]dupeFile
+	var total, count int64
+	for size, files := range bySize {
+		if len(files) < 2 {
+			continue
+		}
+		for _, group := range groupByHash(files, opts.jobs, partialHash) {
+			candidates = append(candidates, group)
+			if size > dupesPartialSize {
+				total += size * int64(len(group))
+				count += int64(len(group))
+			}
+		}
+	}
+
+	prog := newProgress("Hashing", total, count)
+	var sets []dupeSet
+	for _, group := range candidates {
+		size := group[0].info.Size()
+		groups := [][]dupeFile{group}
+		if size > dupesPartialSize {
+			// The partial hash covered small files completely
+			groups = groupByHash(group, opts.jobs, func(path string) (string, error) {
+				defer prog.addFile()
+				return hashFile(path, "sha256", prog)
+			})
+		}
+		for _, files := range groups {
+			sets = append(sets, newDupeSet(size, files))
+		}
+	}
+	prog.finish()
+
+	sort.Slice(sets, func(i, j int) bool {
+		if sets[i].wasted() != sets[j].wasted() {
+			return sets[i].wasted() > sets[j].wasted()
+		}
+		return sets[i].files[0].path < sets[j].files[0].path
+	})
+	return sets, scanned, nil
+}
+
+// newDupeSet orders a set so the oldest file, usually the original that
+// gxdup and gxbackup copied, comes first and is the one kept
+func newDupeSet(size int64, files []dupeFile) dupeSet {
+	sort.Slice(files, func(i, j int) bool {
+		ti, tj := files[i].info.ModTime(), files[j].info.ModTime()
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return files[i].path < files[j].path
+	})
+	return dupeSet{size, files}
+}
+
+// linkDupe atomically replaces dup with a hard link to keep
+func linkDupe(keep, dup string) error {
+	tmp := filepath.Join(filepath.Dir(dup), "."+filepath.Base(dup)+".gxlink")
+	os.Remove(tmp)
+	if err := os.Link(keep, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dup); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// checkDupe re-checks a file right before it is linked or trashed: it must
+// still have the size and modification time seen during the scan, or it may
+// have been edited while the confirmation prompt was waiting
+func checkDupe(file dupeFile) (os.FileInfo, error) {
+	info, err := os.Lstat(file.path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() || info.Size() != file.info.Size() || !info.ModTime().Equal(file.info.ModTime()) {
+		return nil, fmt.Errorf("changed since the scan")
+	}
+	return info, nil
+}
+
+// linkConflict explains why replacing dup with a hard link to keep would
+// change more than its contents, or returns "" when it would not
+func linkConflict(keep, dup os.FileInfo) string {
+	switch {
+	case keep.Mode().Perm() != dup.Mode().Perm():
+		return fmt.Sprintf("its mode %04o differs from the kept file's %04o", dup.Mode().Perm(), keep.Mode().Perm())
+	case !sameOwner(keep, dup):
+		return "its owner differs from the kept file's"
+	}
+	return ""
+}
+
+// removeDupes replaces every redundant copy with a hard link to the kept
+// file, or moves it to the trash, and returns the bytes reclaimed
+func removeDupes(sets []dupeSet, link bool) (int64, error) {
+	action := "Move %d duplicate file(s) to trash, reclaiming %s?"
+	if link {
+		action = "Replace %d duplicate file(s) with hard links, reclaiming %s?"
+	}
+	var count, wasted int64
+	for _, set := range sets {
+		count += int64(len(set.files) - 1)
+		wasted += set.wasted()
+	}
+
+	if isDryRun() {
+		for _, set := range sets {
+			for _, dup := range set.files[1:] {
+				if link {
+					dryRunf("would replace '%s' with a hard link to '%s'", dup.path, set.files[0].path)
+				} else {
+					dryRunf("would move '%s' to trash", dup.path)
+				}
+			}
+		}
+		return 0, nil
+	}
+	if !confirmAction(action, count, formatBytes(wasted)) {
+		return 0, errCancelled
+	}
+
+	var reclaimed int64
+	var lastErr error
+	for _, set := range sets {
+		keep := set.files[0].path
+		keepInfo, err := checkDupe(set.files[0])
+		if err != nil {
+			fmt.Printf("⚠️  Skipping the copies of '%s': %v\n", keep, err)
+			continue
+		}
+		for _, dup := range set.files[1:] {
+			if isSuspiciousPath(dup.path) {
+				fmt.Printf("⚠️  Skipping protected path '%s'\n", dup.path)
+				continue
+			}
+			dupInfo, err := checkDupe(dup)
+			if err != nil {
+				fmt.Printf("⚠️  Skipping '%s': %v\n", dup.path, err)
+				continue
+			}
+			// A hard link shares the kept file's mode and owner
+			if conflict := linkConflict(keepInfo, dupInfo); link && conflict != "" {
+				fmt.Printf("⚠️  Skipping '%s': %s\n", dup.path, conflict)
+				continue
+			}
+			if link {
+				// No stash is needed: undo copies keep, which has the same contents
+				before := journalSnap{State: statePath(dup.path)}
+				if err = linkDupe(keep, dup.path); err == nil {
+					recordJournal(journalLink, "gxdupes", dup.path, keep, before)
+				}
+			} else {
+				before := journalSnap{State: statePath(dup.path)}
+				var id string
+				if id, err = moveToTrash(dup.path); err == nil {
+					recordTrashJournal("gxdupes", dup.path, id, before)
+				}
+			}
+			if err != nil {
+				fmt.Printf("❌ %s: %v\n", dup.path, err)
+				lastErr = err
+				continue
+			}
+			reclaimed += set.size
+		}
+	}
+
+	if link {
+		fmt.Printf("🔗 Replaced duplicates with hard links, reclaiming %s\n", formatBytes(reclaimed))
+	} else {
+		fmt.Printf("🗑️ Moved duplicates to trash, reclaiming %s (restore with gxundo or gxtrash)\n", formatBytes(reclaimed))
+	}
+	return reclaimed, lastErr
+}
+
+// gxdupes reports sets of identical files and optionally removes the copies
+func gxdupes(opts dupesOptions) (int64, error) {
+	for _, dir := range opts.dirs {
+		if !validatePath(dir) {
+			return 0, errInvalidInput
+		}
+	}
+
+	sets, scanned, err := findDupes(opts)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return 0, err
+	}
+	if len(sets) == 0 {
+		fmt.Printf("✅ No duplicate files found (%d file(s) scanned)\n", scanned)
+		return 0, nil
+	}
+
+	var redundant, wasted int64
+	for i, set := range sets {
+		fmt.Printf("\n--- Set %d: %d copies of %s, %s wasted ---\n",
+			i+1, len(set.files), formatBytes(set.size), formatBytes(set.wasted()))
+		for j, file := range set.files {
+			mark := "      "
+			if j == 0 {
+				mark = "keep  "
+			}
+			fmt.Printf("  %s%s\n", mark, file.path)
+		}
+		redundant += int64(len(set.files) - 1)
+		wasted += set.wasted()
+	}
+	fmt.Printf("\n🔎 %d duplicate set(s): %d redundant file(s) wasting %s (%d file(s) scanned)\n",
+		len(sets), redundant, formatBytes(wasted), scanned)
+
+	if !opts.link && !opts.trash {
+		return 0, nil
+	}
+	return removeDupes(sets, opts.link)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestFindDupes(t *testing.T) {
+	configDir := filepath.Join(t.TempDir(), "config")
+	dataHome := filepath.Join(t.TempDir(), "data")
+	t.Setenv("GX_CONFIG_DIR", configDir)
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	t.Chdir(t.TempDir())
+
+	prefix := strings.Repeat("p", dupesPartialSize)
+	files := map[string]string{
+		"small1":   "hello",
+		"small2":   "hello",
+		"small3":   "world", // same size as small1, different contents
+		"big1":     prefix + "tail-a",
+		"big2":     prefix + "tail-a",
+		"big3":     prefix + "tail-b", // differs only after the partial hash
+		"bigalone": prefix + "tail-c",
+		"unique":   "only one of this size",
+		"empty1":   "",
+		"empty2":   "",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(name, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A hard link to a file already scanned is not a duplicate
+	if err := os.Link("small1", "small1-link"); err != nil {
+		t.Fatal(err)
+	}
+
+	sets, scanned, err := findDupes(dupesOptions{jobs: 2, dirs: []string{"."}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 9; scanned != want {
+		t.Errorf("scanned %d files, want %d", scanned, want)
+	}
+
+	var got []string
+	for _, set := range sets {
+		var paths []string
+		for _, f := range set.files {
+			paths = append(paths, f.path)
+		}
+		sort.Strings(paths)
+		got = append(got, strings.Join(paths, " "))
+	}
+	want := []string{"big1 big2", "small1 small2"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("dupe sets = %q, want %q", got, want)
+	}
+
+	// big3 only separates from big1 and big2 on the full hash
+	partial := groupByHash([]dupeFile{{path: "big1"}, {path: "big3"}}, 1, partialHash)
+	if len(partial) != 1 {
+		t.Errorf("partial hash split big1 and big3 into %d groups, want 1", len(partial))
+	}
+
+	for _, dir := range []string{configDir, dataHome} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("report-only scan created %s", dir)
+		}
+	}
+}
@@ -0,0 +1,622 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ==================== TEXT ENCODINGS ====================
+
+// textEncoding names how a text file is stored on disk
+type textEncoding string
+
+const (
+	encUTF8    textEncoding = "utf-8"
+	encUTF8BOM textEncoding = "utf-8-bom"
+	encUTF16LE textEncoding = "utf-16le"
+	encUTF16BE textEncoding = "utf-16be"
+	encLatin1  textEncoding = "latin1"
+	encCP1252  textEncoding = "windows-1252"
+)
+
+// encodingAliases maps the names accepted by gxiconv to encodings
+var encodingAliases = map[string]textEncoding{
+	"utf-8": encUTF8, "utf8": encUTF8,
+	"utf-8-bom": encUTF8BOM, "utf8-bom": encUTF8BOM, "utf-8-sig": encUTF8BOM,
+	"utf-16le": encUTF16LE, "utf16le": encUTF16LE, "utf-16": encUTF16LE, "ucs-2": encUTF16LE,
+	"utf-16be": encUTF16BE, "utf16be": encUTF16BE,
+	"latin1": encLatin1, "latin-1": encLatin1, "iso-8859-1": encLatin1, "iso8859-1": encLatin1,
+	"windows-1252": encCP1252, "cp1252": encCP1252, "win1252": encCP1252,
+}
+
+// cp1252High holds the characters Windows-1252 puts at 0x80-0x9F, where
+// Latin-1 has control codes; zero marks the five unassigned bytes, which
+// map to the control code of the same value as Windows does
+var cp1252High = [32]rune{
+	0x20AC, 0, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0, 0x017D, 0,
+	0, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0, 0x017E, 0x0178,
+}
+
+// bom returns the byte order mark written for the encoding, if any
+func (e textEncoding) bom() []byte {
+	switch e {
+	case encUTF8BOM:
+		return []byte{0xEF, 0xBB, 0xBF}
+	case encUTF16LE:
+		return []byte{0xFF, 0xFE}
+	case encUTF16BE:
+		return []byte{0xFE, 0xFF}
+	}
+	return nil
+}
+
+// isUTF16 reports whether the encoding uses two-byte units
+func (e textEncoding) isUTF16() bool {
+	return e == encUTF16LE || e == encUTF16BE
+}
+
+// parseEncoding resolves an encoding name given by the user
+func parseEncoding(name string) (textEncoding, error) {
+	if enc, ok := encodingAliases[strings.ToLower(name)]; ok {
+		return enc, nil
+	}
+	return "", fmt.Errorf("unknown encoding '%s' (use utf-8, utf-8-bom, utf-16le, utf-16be, latin1 or windows-1252)", name)
+}
+
+// detectEncoding guesses the encoding of a file from its first bytes:
+// a byte order mark, the NUL pattern of BOM-less UTF-16, valid UTF-8, and
+// otherwise Windows-1252 when its extra characters appear, else Latin-1
+func detectEncoding(head []byte) textEncoding {
+	switch {
+	case bytes.HasPrefix(head, encUTF8BOM.bom()):
+		return encUTF8BOM
+	case bytes.HasPrefix(head, encUTF16LE.bom()):
+		return encUTF16LE
+	case bytes.HasPrefix(head, encUTF16BE.bom()):
+		return encUTF16BE
+	}
+
+	// Mostly-ASCII UTF-16 has a zero in every other byte
+	if pairs := len(head) / 2; pairs >= 2 {
+		evenZeros, oddZeros := 0, 0
+		for i := 0; i+1 < len(head); i += 2 {
+			if head[i] == 0 {
+				evenZeros++
+			}
+			if head[i+1] == 0 {
+				oddZeros++
+			}
+		}
+		switch {
+		case oddZeros*10 > pairs*3 && evenZeros*20 < pairs:
+			return encUTF16LE
+		case evenZeros*10 > pairs*3 && oddZeros*20 < pairs:
+			return encUTF16BE
+		}
+	}
+
+	if validUTF8Prefix(head) {
+		return encUTF8
+	}
+	return legacyEncoding(head)
+}
+
+// validUTF8Prefix reports whether data is valid UTF-8, allowing it to end
+// part way through a character because it was cut from a longer file
+func validUTF8Prefix(data []byte) bool {
+	return utf8.Valid(data[:len(data)-incompleteTail(data)])
+}
+
+// incompleteTail returns the length of a character cut off at the end of data
+func incompleteTail(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return len(data) - i
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// legacyEncoding chooses between Windows-1252 and Latin-1 for non-UTF-8 text
+func legacyEncoding(data []byte) textEncoding {
+	for _, c := range data {
+		if c >= 0x80 && c <= 0x9F && cp1252High[c-0x80] != 0 {
+			return encCP1252
+		}
+	}
+	return encLatin1
+}
+
+// detectFileEncoding checks the whole file rather than its first bytes, so a
+// Latin-1 character deep inside an otherwise ASCII file is not missed
+func detectFileEncoding(file *os.File) (textEncoding, error) {
+	head := make([]byte, binarySniffLen)
+	n, err := file.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	enc := detectEncoding(head[:n])
+	if enc != encUTF8 {
+		return enc, nil
+	}
+
+	reader := bufio.NewReaderSize(io.NewSectionReader(file, 0, 1<<62), 64*1024)
+	var carry []byte
+	buf := make([]byte, 64*1024)
+	legacy := encLatin1
+	valid := true
+	for {
+		n, err := reader.Read(buf)
+		chunk := append(carry, buf[:n]...)
+		if legacyEncoding(buf[:n]) == encCP1252 {
+			legacy = encCP1252
+		}
+		// Hold back a character split across reads
+		keep := incompleteTail(chunk)
+		if valid && !utf8.Valid(chunk[:len(chunk)-keep]) {
+			valid = false
+		}
+		carry = append(carry[:0], chunk[len(chunk)-keep:]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	if valid && utf8.Valid(carry) {
+		return encUTF8, nil
+	}
+	return legacy, nil
+}
+
+// isBinaryText is isBinary for data in a known encoding: UTF-16 is full of
+// NUL bytes and Latin-1 is never valid UTF-8, so neither counts against it
+func isBinaryText(data []byte, enc textEncoding) bool {
+	switch {
+	case enc.isUTF16():
+		return false
+	case enc == encLatin1 || enc == encCP1252:
+		if bytes.IndexByte(data, 0) >= 0 {
+			return true
+		}
+		controls := 0
+		for _, c := range data {
+			if c < 0x20 && c != '\n' && c != '\r' && c != '\t' && c != '\f' && c != '\b' && c != 0x1b {
+				controls++
+			}
+		}
+		return float64(controls)/float64(len(data)) > binaryInvalidRatio
+	}
+	return isBinary(data)
+}
+
+// sniffText detects the encoding of an open file and whether it is binary,
+// leaving the file's offset where it was
+func sniffText(file *os.File) (textEncoding, bool) {
+	head := make([]byte, binarySniffLen)
+	n, err := file.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return encUTF8, false
+	}
+	enc := detectEncoding(head[:n])
+	return enc, isBinaryText(head[:n], enc)
+}
+
+// decodeReader converts text in another encoding to UTF-8 as it is read
+type decodeReader struct {
+	src  *bufio.Reader
+	enc  textEncoding
+	pair [2]byte
+	buf  []byte
+	out  []byte
+	err  error
+}
+
+// newDecodeReader returns r decoded to UTF-8 with any byte order mark
+// removed; UTF-8 input is returned unchanged
+func newDecodeReader(r io.Reader, enc textEncoding) io.Reader {
+	if enc == encUTF8 {
+		return r
+	}
+	d := &decodeReader{src: bufio.NewReaderSize(r, 64*1024), enc: enc}
+	if bom := enc.bom(); bom != nil {
+		if head, err := d.src.Peek(len(bom)); err == nil && bytes.Equal(head, bom) {
+			d.src.Discard(len(bom))
+		}
+	}
+	return d
+}
+
+func (d *decodeReader) Read(p []byte) (int, error) {
+	for len(d.out) == 0 && d.err == nil {
+		d.fill()
+	}
+	if len(d.out) == 0 {
+		return 0, d.err
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+// fill decodes the next few thousand characters into d.out
+func (d *decodeReader) fill() {
+	d.buf = d.buf[:0]
+	for len(d.buf) < 16*1024 && d.err == nil {
+		switch d.enc {
+		case encUTF16LE, encUTF16BE:
+			unit, ok := d.readUnit()
+			if !ok {
+				break
+			}
+			r := rune(unit)
+			if utf16.IsSurrogate(r) {
+				next, err := d.src.Peek(2)
+				if err == nil {
+					r2 := rune(d.unit(next))
+					if decoded := utf16.DecodeRune(r, r2); decoded != utf8.RuneError {
+						d.src.Discard(2)
+						r = decoded
+					} else {
+						r = utf8.RuneError
+					}
+				} else {
+					r = utf8.RuneError
+				}
+			}
+			d.buf = utf8.AppendRune(d.buf, r)
+		case encLatin1, encCP1252:
+			c, err := d.src.ReadByte()
+			if err != nil {
+				d.err = err
+				break
+			}
+			r := rune(c)
+			if d.enc == encCP1252 && c >= 0x80 && c <= 0x9F && cp1252High[c-0x80] != 0 {
+				r = cp1252High[c-0x80]
+			}
+			d.buf = utf8.AppendRune(d.buf, r)
+		default:
+			chunk := make([]byte, 16*1024-len(d.buf))
+			n, err := d.src.Read(chunk)
+			d.buf = append(d.buf, chunk[:n]...)
+			d.err = err
+		}
+	}
+	d.out = d.buf
+}
+
+// readUnit reads one UTF-16 code unit; a dangling odd byte becomes U+FFFD
+func (d *decodeReader) readUnit() (uint16, bool) {
+	pair := d.pair[:]
+	n, err := io.ReadFull(d.src, pair)
+	if n == 1 {
+		d.buf = utf8.AppendRune(d.buf, utf8.RuneError)
+	}
+	if err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		d.err = err
+		return 0, false
+	}
+	return d.unit(pair), true
+}
+
+func (d *decodeReader) unit(pair []byte) uint16 {
+	if d.enc == encUTF16BE {
+		return uint16(pair[0])<<8 | uint16(pair[1])
+	}
+	return uint16(pair[1])<<8 | uint16(pair[0])
+}
+
+// encodeText converts UTF-8 text to enc, failing on the first character the
+// encoding cannot represent
+func encodeText(s string, enc textEncoding) ([]byte, error) {
+	switch enc {
+	case encUTF8, encUTF8BOM:
+		return []byte(s), nil
+	case encUTF16LE, encUTF16BE:
+		units := utf16.Encode([]rune(s))
+		out := make([]byte, 0, len(units)*2)
+		for _, u := range units {
+			if enc == encUTF16BE {
+				out = append(out, byte(u>>8), byte(u))
+			} else {
+				out = append(out, byte(u), byte(u>>8))
+			}
+		}
+		return out, nil
+	}
+
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		c, ok := encodeLegacyRune(r, enc)
+		if !ok {
+			return nil, fmt.Errorf("character %q (U+%04X) cannot be represented in %s", r, r, enc)
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// encodeLegacyRune returns the Latin-1 or Windows-1252 byte for r
+func encodeLegacyRune(r rune, enc textEncoding) (byte, bool) {
+	if enc == encCP1252 {
+		for i, high := range cp1252High {
+			if high == r && r != 0 {
+				return byte(0x80 + i), true
+			}
+		}
+		if r >= 0x80 && r <= 0x9F {
+			// Only the unassigned bytes map back to control codes
+			return byte(r), cp1252High[r-0x80] == 0
+		}
+	}
+	if r < 0x100 {
+		return byte(r), true
+	}
+	return 0, false
+}
+
+// ==================== GXICONV ====================
+
+// iconvOptions holds the parsed gxiconv arguments
+type iconvOptions struct {
+	from     textEncoding // "" to detect
+	to       textEncoding // "" to keep the source encoding
+	eol      string       // "\n" or "\r\n" to normalize line endings, "" to keep them
+	patterns []string
+}
+
+// lineEndingStats counts the kinds of line endings in a file
+type lineEndingStats struct {
+	lines int
+	crlf  int
+	lf    int
+}
+
+func (s lineEndingStats) String() string {
+	switch {
+	case s.crlf == 0 && s.lf == 0:
+		return "no line endings"
+	case s.crlf == 0:
+		return "LF"
+	case s.lf == 0:
+		return "CRLF"
+	}
+	return fmt.Sprintf("mixed (%d CRLF, %d LF)", s.crlf, s.lf)
+}
+
+// eolName describes a line ending for messages
+func eolName(eol string) string {
+	if eol == "\r\n" {
+		return "CRLF"
+	}
+	return "LF"
+}
+
+// parseIconvArgs parses "gxiconv [-f FROM] [-t TO] [--lf|--crlf] file|glob..."
+func parseIconvArgs(args []string) (iconvOptions, error) {
+	var opts iconvOptions
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "-f", "-t":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("missing encoding for %s", arg)
+			}
+			i++
+			enc, err := parseEncoding(args[i])
+			if err != nil {
+				return opts, err
+			}
+			if arg == "-f" {
+				opts.from = enc
+			} else {
+				opts.to = enc
+			}
+		case "--lf":
+			opts.eol = "\n"
+		case "--crlf":
+			opts.eol = "\r\n"
+		case "--":
+			opts.patterns = append(opts.patterns, args[i+1:]...)
+			i = len(args)
+		default:
+			opts.patterns = append(opts.patterns, arg)
+		}
+	}
+	if len(opts.patterns) == 0 {
+		return opts, fmt.Errorf("missing filename")
+	}
+	return opts, nil
+}
+
+// convertText decodes r from one encoding and writes it to w in another,
+// normalizing line endings when eol is set
+func convertText(w io.Writer, r io.Reader, from, to textEncoding, eol string) (lineEndingStats, int64, error) {
+	var stats lineEndingStats
+	var written int64
+	out := bufio.NewWriter(w)
+	if bom := to.bom(); bom != nil {
+		n, _ := out.Write(bom)
+		written += int64(n)
+	}
+	err := forEachLine(newDecodeReader(r, from), func(line, end string) error {
+		stats.lines++
+		if end != "" {
+			if strings.HasSuffix(line, "\r") {
+				stats.crlf++
+			} else {
+				stats.lf++
+			}
+			if eol != "" {
+				line, end = strings.TrimSuffix(line, "\r"), eol
+			}
+		}
+		data, err := encodeText(line+end, to)
+		if err != nil {
+			return fmt.Errorf("line %d: %v", stats.lines, err)
+		}
+		n, err := out.Write(data)
+		written += int64(n)
+		return err
+	})
+	if err != nil {
+		return stats, written, err
+	}
+	return stats, written, out.Flush()
+}
+
+// iconvPlan is the conversion worked out for one file
+type iconvPlan struct {
+	path  string
+	from  textEncoding
+	to    textEncoding
+	stats lineEndingStats
+}
+
+// planIconv works out and validates the conversion of one file without
+// writing anything; it returns nil when the file would not change
+func planIconv(path string, opts iconvOptions) (*iconvPlan, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	plan := &iconvPlan{path: path, from: opts.from, to: opts.to}
+	if plan.from == "" {
+		if plan.from, err = detectFileEncoding(file); err != nil {
+			return nil, err
+		}
+	}
+	if plan.to == "" {
+		plan.to = plan.from
+	}
+	plan.stats, _, err = convertText(io.Discard, file, plan.from, plan.to, opts.eol)
+	if err != nil {
+		return nil, err
+	}
+
+	eolChanges := (opts.eol == "\n" && plan.stats.crlf > 0) || (opts.eol == "\r\n" && plan.stats.lf > 0)
+	if plan.from == plan.to && !eolChanges {
+		return nil, nil
+	}
+	return plan, nil
+}
+
+// describe summarizes the plan as "utf-16le → utf-8, CRLF → LF"
+func (p *iconvPlan) describe(eol string) string {
+	var parts []string
+	if p.from != p.to {
+		parts = append(parts, fmt.Sprintf("%s → %s", p.from, p.to))
+	}
+	if eol != "" && p.stats.String() != eolName(eol) && (p.stats.crlf > 0 || p.stats.lf > 0) {
+		parts = append(parts, fmt.Sprintf("%s → %s", p.stats, eolName(eol)))
+	}
+	return fmt.Sprintf("%s: %s (%d line(s))", p.path, strings.Join(parts, ", "), p.stats.lines)
+}
+
+// applyIconv rewrites the file atomically in the new encoding
+func applyIconv(plan *iconvPlan, eol string) (int64, error) {
+	in, err := os.Open(plan.path)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	var written int64
+	err = atomicWrite(plan.path, 0644, func(w io.Writer) error {
+		var err error
+		_, written, err = convertText(w, in, plan.from, plan.to, eol)
+		return err
+	})
+	return written, err
+}
+
+// reportEncodings prints the detected encoding and line endings of each file
+func reportEncodings(files []string) {
+	for _, path := range files {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Printf("Error opening file '%s': %v\n", path, err)
+			continue
+		}
+		enc, err := detectFileEncoding(file)
+		if err == nil {
+			var stats lineEndingStats
+			stats, _, err = convertText(io.Discard, file, enc, encUTF8, "")
+			if err == nil {
+				fmt.Printf("%s: %s, %s line endings, %d line(s)\n", path, enc, stats, stats.lines)
+			}
+		}
+		file.Close()
+		if err != nil {
+			fmt.Printf("Error reading file '%s': %v\n", path, err)
+		}
+	}
+}
+
+// gxiconv converts files between encodings and normalizes line endings.
+// Without -t, --lf or --crlf it only reports what it detects.
+func gxiconv(opts iconvOptions, files []string) (int64, error) {
+	if opts.to == "" && opts.eol == "" {
+		reportEncodings(files)
+		return 0, nil
+	}
+
+	var plans []*iconvPlan
+	for _, path := range files {
+		plan, err := planIconv(path, opts)
+		if err != nil {
+			fmt.Printf("❌ Cannot convert '%s': %v\n", path, err)
+			return 0, err
+		}
+		if plan != nil {
+			plans = append(plans, plan)
+		}
+	}
+	if len(plans) == 0 {
+		fmt.Printf("Nothing to convert in %d file(s)\n", len(files))
+		return 0, nil
+	}
+
+	for _, plan := range plans {
+		fmt.Println("  " + plan.describe(opts.eol))
+	}
+	if isDryRun() {
+		dryRunf("would convert %d file(s)", len(plans))
+		return 0, nil
+	}
+	if !confirmAction("Convert %d file(s)?", len(plans)) {
+		return 0, errCancelled
+	}
+
+	var written int64
+	for _, plan := range plans {
+		before := journalCapture(plan.path)
+		n, err := applyIconv(plan, opts.eol)
+		if err != nil {
+			fmt.Printf("Error writing file '%s': %v\n", plan.path, err)
+			return written, err
+		}
+		recordJournal(journalModify, "gxiconv", plan.path, "", before)
+		written += n
+		fmt.Printf("✅ Converted %s\n", plan.path)
+	}
+	fmt.Printf("--- Converted %d file(s) ---\n", len(plans))
+	return written, nil
+}
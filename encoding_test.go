@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// utf16be encodes s as UTF-16BE without a byte order mark
+func utf16be(s string) []byte {
+	le := utf16le(s)
+	for i := 0; i+1 < len(le); i += 2 {
+		le[i], le[i+1] = le[i+1], le[i]
+	}
+	return le
+}
+
+func TestDetectEncoding(t *testing.T) {
+	text := "hello, world\nsecond line\n"
+	tests := []struct {
+		name string
+		data []byte
+		want textEncoding
+	}{
+		{"ascii", []byte(text), encUTF8},
+		{"utf-8", []byte("naïve café\n"), encUTF8},
+		{"utf-8 cut mid-character", []byte("café")[:4], encUTF8},
+		{"utf-8 bom", append([]byte{0xEF, 0xBB, 0xBF}, text...), encUTF8BOM},
+		{"utf-16le bom", append([]byte{0xFF, 0xFE}, utf16le(text)...), encUTF16LE},
+		{"utf-16be bom", append([]byte{0xFE, 0xFF}, utf16be(text)...), encUTF16BE},
+		{"utf-16le without bom", utf16le(text), encUTF16LE},
+		{"utf-16be without bom", utf16be(text), encUTF16BE},
+		{"latin-1", []byte("caf\xe9 cr\xe8me\n"), encLatin1},
+		{"windows-1252 quotes", []byte("\x93quoted\x94 caf\xe9\n"), encCP1252},
+		{"windows-1252 euro", []byte("5 \x80\n"), encCP1252},
+		{"unassigned 0x81 stays latin-1", []byte("a\x81b\xe9"), encLatin1},
+	}
+	for _, tt := range tests {
+		if got := detectEncoding(tt.data); got != tt.want {
+			t.Errorf("%s: detectEncoding = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeReader(t *testing.T) {
+	// Enough characters that the emoji straddles the 16 KB fill boundary
+	long := strings.Repeat("a", 16*1024-1)
+	tests := []struct {
+		name string
+		data []byte
+		enc  textEncoding
+		want string
+	}{
+		{"bom removed", append([]byte{0xFF, 0xFE}, utf16le("hi")...), encUTF16LE, "hi"},
+		{"big endian bom removed", append([]byte{0xFE, 0xFF}, utf16be("hi")...), encUTF16BE, "hi"},
+		{"surrogate pair", utf16le("a😀b"), encUTF16LE, "a😀b"},
+		{"surrogate pair across fills", utf16le(long + "😀" + long + "😀"), encUTF16LE, long + "😀" + long + "😀"},
+		{"dangling odd byte", append(utf16le("hi"), 'x'), encUTF16LE, "hi�"},
+		{"lone high surrogate", append(utf16le("a"), 0x3D, 0xD8), encUTF16LE, "a�"},
+		{"high surrogate without its pair", append(utf16le("a"), 0x3D, 0xD8, 'b', 0), encUTF16LE, "a�b"},
+		{"latin-1", []byte("caf\xe9 \x80"), encLatin1, "café \u0080"},
+		{"windows-1252", []byte("\x93caf\xe9\x94 \x80"), encCP1252, "“café” €"},
+		{"utf-8 bom removed", []byte("\xEF\xBB\xBFhi"), encUTF8BOM, "hi"},
+	}
+	for _, tt := range tests {
+		// One byte per read, so every multi-byte unit spans several reads
+		for _, r := range []io.Reader{bytes.NewReader(tt.data), iotest.OneByteReader(bytes.NewReader(tt.data))} {
+			got, err := io.ReadAll(newDecodeReader(r, tt.enc))
+			if err != nil || string(got) != tt.want {
+				t.Errorf("%s: decoded %.40q, %v; want %.40q", tt.name, got, err, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestEncodeLegacyRune(t *testing.T) {
+	tests := []struct {
+		r    rune
+		enc  textEncoding
+		want byte
+		ok   bool
+	}{
+		{'a', encLatin1, 'a', true},
+		{'é', encLatin1, 0xE9, true},
+		{'€', encLatin1, 0, false},
+		{'€', encCP1252, 0x80, true},
+		{'“', encCP1252, 0x93, true},
+		{'é', encCP1252, 0xE9, true},
+		{0x80, encCP1252, 0, false}, // 0x80 is the euro sign there
+		{0x81, encCP1252, 0x81, true},
+		{'→', encCP1252, 0, false},
+		{'日', encLatin1, 0, false},
+		{'😀', encCP1252, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := encodeLegacyRune(tt.r, tt.enc)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("encodeLegacyRune(%q, %s) = %#x, %v; want %#x, %v", tt.r, tt.enc, got, ok, tt.want, tt.ok)
+		}
+	}
+	if _, err := encodeText("price: 5€", encLatin1); err == nil {
+		t.Error("encodeText accepted a euro sign in latin1")
+	}
+}
+
+func TestConvertTextRoundTrip(t *testing.T) {
+	text := "first line\r\nsecond 😀 line\r\nthird\r\n"
+	original := append([]byte{0xFF, 0xFE}, utf16le(text)...)
+
+	var utf8 bytes.Buffer
+	stats, _, err := convertText(&utf8, bytes.NewReader(original), encUTF16LE, encUTF8, "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.ReplaceAll(text, "\r\n", "\n"); utf8.String() != want {
+		t.Errorf("utf-16le -> utf-8 --lf = %q, want %q", utf8.String(), want)
+	}
+	if stats.lines != 3 || stats.crlf != 3 || stats.lf != 0 {
+		t.Errorf("line ending stats = %+v, want 3 CRLF lines", stats)
+	}
+
+	var back bytes.Buffer
+	stats, written, err := convertText(&back, &utf8, encUTF8, encUTF16LE, "\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(back.Bytes(), original) || written != int64(len(original)) {
+		t.Errorf("utf-8 -> utf-16le --crlf did not restore the original:\n%q\n%q", back.Bytes(), original)
+	}
+	if stats.lf != 3 || stats.crlf != 0 {
+		t.Errorf("line ending stats = %+v, want 3 LF lines", stats)
+	}
+
+	var latin bytes.Buffer
+	if _, _, err := convertText(&latin, bytes.NewReader(original), encUTF16LE, encLatin1, ""); err == nil ||
+		!strings.Contains(err.Error(), "line 2") {
+		t.Errorf("converting an emoji to latin1 = %v, want an error for line 2", err)
+	}
+}
+
+func TestPlanIconv(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	utf16 := write("u16.txt", append([]byte{0xFF, 0xFE}, utf16le("a\r\nb\r\n")...))
+	ascii := write("lf.txt", []byte("a\nb\n"))
+
+	tests := []struct {
+		name string
+		path string
+		opts iconvOptions
+		want *iconvPlan // nil when the file would not change
+	}{
+		{"detected utf-16 to utf-8", utf16, iconvOptions{to: encUTF8},
+			&iconvPlan{utf16, encUTF16LE, encUTF8, lineEndingStats{2, 2, 0}}},
+		{"line endings only", utf16, iconvOptions{eol: "\n"},
+			&iconvPlan{utf16, encUTF16LE, encUTF16LE, lineEndingStats{2, 2, 0}}},
+		{"already crlf", utf16, iconvOptions{eol: "\r\n"}, nil},
+		{"already utf-8 and lf", ascii, iconvOptions{to: encUTF8, eol: "\n"}, nil},
+		{"lf to crlf", ascii, iconvOptions{eol: "\r\n"},
+			&iconvPlan{ascii, encUTF8, encUTF8, lineEndingStats{2, 0, 2}}},
+	}
+	for _, tt := range tests {
+		got, err := planIconv(tt.path, tt.opts)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("%s: planIconv = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,568 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==================== FIND ====================
+
+// gxfind evaluates a find-style expression against every entry under the
+// start paths. Predicates can be combined with -a/-and (implicit), -o/-or,
+// !/-not and parentheses. Actions (-print, -delete, -exec) are not part of
+// the expression: they run on every match once the walk has finished, so the
+// tree is never modified while it is being walked.
+
+// findEntry is a file seen during a gxfind walk
+type findEntry struct {
+	path  string
+	info  os.FileInfo // from Lstat, so symlinks are not followed
+	depth int
+}
+
+// findPredicate tests one entry
+type findPredicate func(e *findEntry) bool
+
+// findOptions holds a parsed gxfind command line
+type findOptions struct {
+	paths    []string
+	expr     findPredicate
+	minDepth int
+	maxDepth int // -1 means unlimited
+	print    bool
+	delete   bool
+	noIndex  bool       // always walk, even when a gxindex index exists
+	exec     [][]string // gx commands with {} placeholders
+}
+
+// findParser is a recursive-descent parser for gxfind expressions
+type findParser struct {
+	args []string
+	pos  int
+	opts *findOptions
+	now  time.Time
+}
+
+// parseFindArgs parses "gxfind [paths...] [expression] [actions]".
+// A single bare word with no expression keeps the original behaviour of
+// matching file names that contain it.
+func parseFindArgs(args []string) (*findOptions, error) {
+	opts := &findOptions{maxDepth: -1}
+
+	i := 0
+	for i < len(args) && !isFindOperator(args[i]) {
+		i++
+	}
+	opts.paths = args[:i]
+
+	if i == len(args) && len(opts.paths) == 1 {
+		name := opts.paths[0]
+		if !validateSearchTerm(name) {
+			return nil, errInvalidInput
+		}
+		opts.paths = []string{"."}
+		opts.expr = func(e *findEntry) bool { return strings.Contains(e.info.Name(), name) }
+		opts.print = true
+		return opts, nil
+	}
+	if len(opts.paths) == 0 {
+		opts.paths = []string{"."}
+	}
+
+	p := &findParser{args: args[i:], opts: opts, now: time.Now()}
+	if len(p.args) > 0 {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos < len(p.args) {
+			return nil, fmt.Errorf("unexpected '%s'", p.args[p.pos])
+		}
+		opts.expr = expr
+	}
+	if opts.expr == nil {
+		opts.expr = func(*findEntry) bool { return true }
+	}
+	if !opts.delete && len(opts.exec) == 0 {
+		opts.print = true
+	}
+	return opts, nil
+}
+
+// isFindOperator reports whether an argument starts the expression
+func isFindOperator(arg string) bool {
+	return strings.HasPrefix(arg, "-") || arg == "!" || arg == "(" || arg == ")"
+}
+
+// peek returns the next token or ""
+func (p *findParser) peek() string {
+	if p.pos < len(p.args) {
+		return p.args[p.pos]
+	}
+	return ""
+}
+
+// value consumes the argument of an option such as -name
+func (p *findParser) value(option string) (string, error) {
+	if p.pos >= len(p.args) {
+		return "", fmt.Errorf("missing argument to %s", option)
+	}
+	p.pos++
+	return p.args[p.pos-1], nil
+}
+
+// parseOr parses: and { -o and }
+func (p *findParser) parseOr() (findPredicate, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for tok := p.peek(); tok == "-o" || tok == "-or"; tok = p.peek() {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(e *findEntry) bool { return l(e) || right(e) }
+	}
+	return left, nil
+}
+
+// parseAnd parses: unary { [-a] unary }
+func (p *findParser) parseAnd() (findPredicate, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok == "" || tok == ")" || tok == "-o" || tok == "-or" {
+			return left, nil
+		}
+		if tok == "-a" || tok == "-and" {
+			p.pos++
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(e *findEntry) bool { return l(e) && right(e) }
+	}
+}
+
+// parseUnary parses: ! unary | ( or ) | primary
+func (p *findParser) parseUnary() (findPredicate, error) {
+	switch tok := p.peek(); tok {
+	case "":
+		return nil, fmt.Errorf("expression ends unexpectedly")
+	case "!", "-not":
+		p.pos++
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(e *findEntry) bool { return !inner(e) }, nil
+	case "(":
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		p.pos++
+		return inner, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary parses a single test, option or action
+func (p *findParser) parsePrimary() (findPredicate, error) {
+	tok := p.args[p.pos]
+	p.pos++
+	always := func(*findEntry) bool { return true }
+
+	switch tok {
+	case "-name", "-iname":
+		pattern, err := p.value(tok)
+		if err != nil {
+			return nil, err
+		}
+		if tok == "-iname" {
+			pattern = strings.ToLower(pattern)
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s' for %s", pattern, tok)
+		}
+		return func(e *findEntry) bool {
+			name := e.info.Name()
+			if tok == "-iname" {
+				name = strings.ToLower(name)
+			}
+			ok, _ := filepath.Match(pattern, name)
+			return ok
+		}, nil
+
+	case "-regex":
+		pattern, err := p.value(tok)
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex '%s': %v", pattern, err)
+		}
+		return func(e *findEntry) bool {
+			// find prints paths under "." as "./sub/file" and patterns are
+			// written for that form, so try it as well as the walked path
+			path := filepath.ToSlash(e.path)
+			if re.MatchString(path) {
+				return true
+			}
+			return path != "." && !strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "./") &&
+				re.MatchString("./"+path)
+		}, nil
+
+	case "-type":
+		kind, err := p.value(tok)
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case "f":
+			return func(e *findEntry) bool { return e.info.Mode().IsRegular() }, nil
+		case "d":
+			return func(e *findEntry) bool { return e.info.IsDir() }, nil
+		case "l":
+			return func(e *findEntry) bool { return e.info.Mode()&os.ModeSymlink != 0 }, nil
+		}
+		return nil, fmt.Errorf("unknown type '%s' (use f, d or l)", kind)
+
+	case "-size":
+		spec, err := p.value(tok)
+		if err != nil {
+			return nil, err
+		}
+		cmp, unit, n, err := parseFindSize(spec)
+		if err != nil {
+			return nil, err
+		}
+		return func(e *findEntry) bool {
+			if e.info.IsDir() {
+				return false
+			}
+			// Like find, sizes are rounded up to whole units before comparing
+			size := int64(math.Ceil(float64(e.info.Size()) / float64(unit)))
+			return compareFind(cmp, size, n)
+		}, nil
+
+	case "-mtime":
+		spec, err := p.value(tok)
+		if err != nil {
+			return nil, err
+		}
+		cmp, unit, n, err := parseFindAge(spec)
+		if err != nil {
+			return nil, err
+		}
+		return func(e *findEntry) bool {
+			age := int64(p.now.Sub(e.info.ModTime()) / unit)
+			return compareFind(cmp, age, n)
+		}, nil
+
+	case "-empty":
+		return func(e *findEntry) bool {
+			if e.info.IsDir() {
+				entries, err := os.ReadDir(e.path)
+				return err == nil && len(entries) == 0
+			}
+			return e.info.Mode().IsRegular() && e.info.Size() == 0
+		}, nil
+
+	case "-maxdepth", "-mindepth":
+		value, err := p.value(tok)
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid depth '%s' for %s", value, tok)
+		}
+		if tok == "-maxdepth" {
+			p.opts.maxDepth = n
+		} else {
+			p.opts.minDepth = n
+		}
+		return always, nil
+
+	case "-print":
+		p.opts.print = true
+		return always, nil
+
+	case "--no-index":
+		p.opts.noIndex = true
+		return always, nil
+
+	case "-delete":
+		p.opts.delete = true
+		return always, nil
+
+	case "-exec":
+		var command []string
+		for p.pos < len(p.args) && p.args[p.pos] != ";" && p.args[p.pos] != `\;` {
+			command = append(command, p.args[p.pos])
+			p.pos++
+		}
+		if p.pos < len(p.args) {
+			p.pos++ // skip the terminator
+		}
+		if len(command) == 0 {
+			return nil, fmt.Errorf("missing command for -exec")
+		}
+		if !strings.HasPrefix(command[0], "gx") || command[0] == "gxfind" {
+			return nil, fmt.Errorf("-exec only runs gx commands (got '%s')", command[0])
+		}
+		p.opts.exec = append(p.opts.exec, command)
+		return always, nil
+	}
+
+	return nil, fmt.Errorf("unknown predicate '%s'", tok)
+}
+
+// compareFind compares a value against n using a find-style '+', '-' or exact match
+func compareFind(cmp byte, value, n int64) bool {
+	switch cmp {
+	case '+':
+		return value > n
+	case '-':
+		return value < n
+	}
+	return value == n
+}
+
+// splitFindNumber splits "+10M" into '+', 10 and "M"
+func splitFindNumber(spec string) (byte, int64, string, bool) {
+	var cmp byte
+	if strings.HasPrefix(spec, "+") || strings.HasPrefix(spec, "-") {
+		cmp, spec = spec[0], spec[1:]
+	}
+	digits := strings.TrimRightFunc(spec, func(r rune) bool { return r < '0' || r > '9' })
+	n, err := strconv.ParseInt(digits, 10, 64)
+	return cmp, n, spec[len(digits):], err == nil
+}
+
+// parseFindSize parses -size specs such as +10M, -512k or 0 (bytes by default)
+func parseFindSize(spec string) (byte, int64, int64, error) {
+	cmp, n, suffix, ok := splitFindNumber(spec)
+	units := map[string]int64{"": 1, "c": 1, "k": 1 << 10, "K": 1 << 10, "M": 1 << 20, "G": 1 << 30}
+	unit, known := units[suffix]
+	if !ok || !known {
+		return 0, 0, 0, fmt.Errorf("invalid size '%s' (use e.g. +10M, -512k, 100c)", spec)
+	}
+	return cmp, unit, n, nil
+}
+
+// parseFindAge parses -mtime specs such as -2d, +1w or 3 (days by default)
+func parseFindAge(spec string) (byte, time.Duration, int64, error) {
+	cmp, n, suffix, ok := splitFindNumber(spec)
+	units := map[string]time.Duration{
+		"": 24 * time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour,
+		"h": time.Hour, "m": time.Minute, "s": time.Second,
+	}
+	unit, known := units[suffix]
+	if !ok || !known {
+		return 0, 0, 0, fmt.Errorf("invalid age '%s' (use e.g. -2d, +1w, -12h)", spec)
+	}
+	return cmp, unit, n, nil
+}
+
+// findMatches walks the start paths and returns every entry matching the
+// expression. Paths covered by a gxindex index are answered from the index,
+// unless the expression has -delete or -exec.
+func findMatches(opts *findOptions) ([]string, error) {
+	for _, root := range opts.paths {
+		if !validatePath(root) {
+			return nil, errInvalidInput
+		}
+	}
+
+	prog := newProgress("Searching", 0, 0)
+	defer prog.finish()
+
+	var matches []string
+	check := func(path string, info os.FileInfo, depth int) {
+		prog.addFile()
+		entry := &findEntry{path: path, info: info, depth: depth}
+		if depth >= opts.minDepth && opts.expr(entry) {
+			matches = append(matches, path)
+			if opts.print {
+				prog.printf("  📍 %s\n", path)
+			}
+		}
+	}
+
+	// Actions change files, so they must act on what is on disk now and
+	// never on a possibly stale index
+	useIndex := !opts.noIndex && !opts.delete && len(opts.exec) == 0
+
+	var idx *fileIndex
+	for _, root := range opts.paths {
+		if useIndex {
+			if idx == nil || !isSubPath(idx.root, root) {
+				if idx = findIndex(root); idx != nil {
+					indexNotice(idx)
+				}
+			}
+			if idx != nil && walkIndex(idx, root, func(path string, info os.FileInfo, depth int) {
+				if opts.maxDepth < 0 || depth <= opts.maxDepth {
+					check(path, info, depth)
+				}
+			}) {
+				continue
+			}
+		}
+
+		rootDepth := strings.Count(filepath.Clean(root), string(filepath.Separator))
+		if filepath.Clean(root) == "." {
+			rootDepth = -1
+		}
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == root {
+					return err
+				}
+				prog.printf("⚠️  %v\n", err)
+				return nil
+			}
+
+			depth := 0
+			if path != root {
+				depth = strings.Count(filepath.Clean(path), string(filepath.Separator)) - rootDepth
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			check(path, info, depth)
+
+			if d.IsDir() && opts.maxDepth >= 0 && depth >= opts.maxDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			return matches, err
+		}
+	}
+	return matches, nil
+}
+
+// findDelete moves every match to the trash after a single confirmation.
+// Matches inside a directory that is trashed as well are skipped, since they
+// go to the trash with it.
+func findDelete(matches []string) (int64, error) {
+	var targets []string
+	for _, path := range matches {
+		if filepath.Clean(path) == "." {
+			continue
+		}
+		if isSuspiciousPath(path) {
+			fmt.Printf("❌ Error: Access denied - Cannot delete '%s'\n", path)
+			return 0, errInvalidInput
+		}
+		if len(targets) > 0 && isSubPath(targets[len(targets)-1], path) {
+			continue
+		}
+		targets = append(targets, path)
+	}
+	if len(targets) == 0 {
+		return 0, nil
+	}
+
+	if isDryRun() {
+		for _, path := range targets {
+			dryRunf("would move '%s' to trash", path)
+		}
+		return 0, nil
+	}
+	if !confirmAction("Move %d matching item(s) to trash?", len(targets)) {
+		return 0, errCancelled
+	}
+
+	var total int64
+	for _, path := range targets {
+		size, _ := dirSize(path)
+		before := journalSnap{State: statePath(path)}
+		id, err := moveToTrash(path)
+		if err != nil {
+			fmt.Printf("Error moving '%s' to trash: %v\n", path, err)
+			return total, err
+		}
+		recordTrashJournal("gxfind", path, id, before)
+		total += size
+	}
+	fmt.Printf("🗑️ %d item(s) moved to trash (see: gxtrash list)\n", len(targets))
+	return total, nil
+}
+
+// findExec runs each -exec command on every match, replacing {} with the path.
+// The global -y/--dry-run flags given to gxfind are passed on to each command.
+func findExec(opts *findOptions, matches []string) {
+	outer := current
+	findExecRunning = true
+	defer func() { current, findExecRunning = outer, false }()
+
+	for _, path := range matches {
+		for _, command := range opts.exec {
+			// Global flags only count right after the command name
+			parts := []string{command[0]}
+			if outer.dryRun {
+				parts = append(parts, "--dry-run")
+			}
+			if outer.assumeYes {
+				parts = append(parts, "-y")
+			}
+			for _, arg := range command[1:] {
+				parts = append(parts, strings.ReplaceAll(arg, "{}", path))
+			}
+			handleCommand(parts[0], parts)
+		}
+	}
+}
+
+// gxfind finds files matching a find-style expression and runs its actions
+func gxfind(opts *findOptions) (int64, error) {
+	matches, err := findMatches(opts)
+	if err != nil {
+		if err != errInvalidInput {
+			fmt.Printf("Error during search: %v\n", err)
+		}
+		return 0, err
+	}
+
+	if opts.print {
+		if len(matches) == 0 {
+			fmt.Println("No matching files found")
+		} else {
+			fmt.Printf("Found %d matching file(s)\n", len(matches))
+		}
+	}
+
+	if len(opts.exec) > 0 {
+		findExec(opts, matches)
+	}
+	if opts.delete {
+		return findDelete(matches)
+	}
+	return 0, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeInfo is an os.FileInfo for entries that do not exist on disk
+type fakeInfo struct {
+	name string
+	size int64
+	mode os.FileMode
+}
+
+func (f fakeInfo) Name() string       { return f.name }
+func (f fakeInfo) Size() int64        { return f.size }
+func (f fakeInfo) Mode() os.FileMode  { return f.mode }
+func (f fakeInfo) ModTime() time.Time { return time.Now() }
+func (f fakeInfo) IsDir() bool        { return f.mode.IsDir() }
+func (f fakeInfo) Sys() interface{}   { return nil }
+
+func TestFindExpression(t *testing.T) {
+	entries := []*findEntry{
+		{path: "a.go", info: fakeInfo{"a.go", 100, 0644}},
+		{path: "big.go", info: fakeInfo{"big.go", 5 << 20, 0644}},
+		{path: "notes.txt", info: fakeInfo{"notes.txt", 10, 0644}},
+		{path: "src", info: fakeInfo{"src", 0, os.ModeDir | 0755}},
+		{path: "src/main.go", info: fakeInfo{"main.go", 2000, 0644}},
+	}
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"-name *.go", "a.go big.go src/main.go"},
+		{"-type d", "src"},
+		// -a binds tighter than -o
+		{"-name *.txt -o -name *.go -size +1M", "big.go notes.txt"},
+		{"-name *.txt -o -name *.go -a -size +1M", "big.go notes.txt"},
+		{"( -name *.txt -o -name *.go ) -size -2k", "a.go notes.txt"},
+		{"! -name *.go -type f", "notes.txt"},
+		{"-not ( -name a.go -o -type d )", "big.go notes.txt src/main.go"},
+		{"! ! -name a.go", "a.go"},
+		{"-regex .*/main\\.go", "src/main.go"},
+		{"-regex \\./src/.*", "src/main.go"},
+		{"-regex [a-z]+\\.go", "a.go big.go"},
+		{"-iname NOTES.*", "notes.txt"},
+	}
+	for _, tt := range tests {
+		opts, err := parseFindArgs(append([]string{"."}, strings.Fields(tt.expr)...))
+		if err != nil {
+			t.Errorf("parseFindArgs(%q): %v", tt.expr, err)
+			continue
+		}
+		var got []string
+		for _, e := range entries {
+			if opts.expr(e) {
+				got = append(got, e.path)
+			}
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%q matched %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestFindParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"( -name a",
+		"-name",
+		"-type x",
+		"-size 10X",
+		"-name a )",
+		"-exec rm {} ;",
+		"-bogus",
+	} {
+		if _, err := parseFindArgs(append([]string{"."}, strings.Fields(expr)...)); err == nil {
+			t.Errorf("parseFindArgs(%q) succeeded, want an error", expr)
+		}
+	}
+}
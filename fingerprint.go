@@ -0,0 +1,411 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ==================== TREE FINGERPRINTS ====================
+
+// snapshotVersion is written to snapshot files so the format can evolve
+const snapshotVersion = 1
+
+// treeEntry is one file, directory or symlink below a fingerprinted root
+type treeEntry struct {
+	Path string `json:"path"` // relative to the root, with forward slashes
+	Type string `json:"type"` // "file", "dir" or "symlink"
+	Mode string `json:"mode"` // permission bits in octal
+	Size int64  `json:"size"`
+	Hash string `json:"hash"` // contents, link target or (for directories) subtree
+}
+
+// treeSnapshot is the state of a tree as saved by gxfingerprint --save
+type treeSnapshot struct {
+	Version     int         `json:"version"`
+	Root        string      `json:"root"` // relative to the snapshot file's directory once saved
+	Created     string      `json:"created"`
+	Fingerprint string      `json:"fingerprint"`
+	Entries     []treeEntry `json:"entries"`
+}
+
+// fingerprintOptions holds the parsed gxfingerprint arguments
+type fingerprintOptions struct {
+	save string // snapshot file to write
+	jobs int
+	dirs []string
+}
+
+// parseFingerprintArgs parses "gxfingerprint [--save FILE] [-j N] [dir...]"
+func parseFingerprintArgs(args []string) (fingerprintOptions, error) {
+	opts := fingerprintOptions{jobs: runtime.NumCPU()}
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--save", "-j":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("missing value for %s", arg)
+			}
+			i++
+			if arg == "--save" {
+				opts.save = args[i]
+				break
+			}
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				return opts, fmt.Errorf("invalid job count '%s'", args[i])
+			}
+			opts.jobs = n
+		default:
+			if len(arg) > 1 && arg[0] == '-' {
+				return opts, fmt.Errorf("unknown option %s", arg)
+			}
+			opts.dirs = append(opts.dirs, arg)
+		}
+	}
+	if len(opts.dirs) == 0 {
+		opts.dirs = []string{"."}
+	}
+	if opts.save != "" && len(opts.dirs) > 1 {
+		return opts, fmt.Errorf("--save takes a single directory")
+	}
+	return opts, nil
+}
+
+// subtreeHash combines the entries of one directory, sorted by name, into
+// its hash. Each entry contributes its type, mode, name and hash, so
+// renaming or chmod-ing a file changes every hash up to the root.
+func subtreeHash(entries []treeEntry, children []int) string {
+	sort.Slice(children, func(i, j int) bool {
+		return entries[children[i]].Path < entries[children[j]].Path
+	})
+	h := sha256.New()
+	for _, i := range children {
+		e := entries[i]
+		fmt.Fprintf(h, "%s %s %s\x00%s\n", e.Type, e.Mode, path.Base(e.Path), e.Hash)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// scanTree fingerprints everything below root. Symlinks are hashed by their
+// target and not followed; sockets and devices are ignored, as is exclude
+// (the snapshot file itself) and the file output is redirected to.
+func scanTree(root, exclude string, jobs int) (*treeSnapshot, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("'%s' is not a directory", root)
+	}
+
+	var entries []treeEntry
+	var files []int
+	var total int64
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		if abs, _ := filepath.Abs(p); abs == exclude || isRedirectTarget(p) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, p)
+		entry := treeEntry{Path: filepath.ToSlash(rel), Mode: fmt.Sprintf("%04o", info.Mode().Perm())}
+		switch {
+		case d.IsDir():
+			entry.Type = "dir"
+		case d.Type().IsRegular():
+			entry.Type, entry.Size = "file", info.Size()
+			files = append(files, len(entries))
+			total += info.Size()
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			sum := sha256.Sum256([]byte(target))
+			entry.Type, entry.Hash = "symlink", hex.EncodeToString(sum[:])
+		default:
+			return nil
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	prog := newProgress("Fingerprinting", total, int64(len(files)))
+	results := hashParallel(len(files), jobs, func(i int) (string, error) {
+		defer prog.addFile()
+		return hashFile(filepath.Join(root, filepath.FromSlash(entries[files[i]].Path)), "sha256", prog)
+	})
+	for i, idx := range files {
+		res := <-results[i]
+		if res.err != nil {
+			// Drain the remaining workers before giving up
+			for _, r := range results[i+1:] {
+				<-r
+			}
+			prog.finish()
+			return nil, res.err
+		}
+		entries[idx].Hash = res.sum
+	}
+	prog.finish()
+
+	// WalkDir lists parents before their children, so walking backwards
+	// finishes every directory's children before the directory itself
+	children := make(map[string][]int)
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Type == "dir" {
+			entries[i].Hash = subtreeHash(entries, children[entries[i].Path])
+		}
+		parent := path.Dir(entries[i].Path)
+		if parent == "." {
+			parent = ""
+		}
+		children[parent] = append(children[parent], i)
+	}
+
+	return &treeSnapshot{
+		Version:     snapshotVersion,
+		Root:        filepath.ToSlash(root),
+		Created:     time.Now().Format(time.RFC3339),
+		Fingerprint: subtreeHash(entries, children[""]),
+		Entries:     entries,
+	}, nil
+}
+
+// countEntries returns the number of files and directories in a snapshot
+func countEntries(snap *treeSnapshot) (int, int) {
+	files, dirs := 0, 0
+	for _, e := range snap.Entries {
+		if e.Type == "dir" {
+			dirs++
+		} else {
+			files++
+		}
+	}
+	return files, dirs
+}
+
+// saveSnapshot writes a snapshot as JSON; gxundo restores the previous file
+func saveSnapshot(snap *treeSnapshot, file string) (int64, error) {
+	// Store the root relative to the snapshot so gxchanged finds the tree
+	// from any working directory, and after both are moved together
+	absRoot, err := filepath.Abs(filepath.FromSlash(snap.Root))
+	if err != nil {
+		return 0, err
+	}
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return 0, err
+	}
+	rel, err := filepath.Rel(filepath.Dir(absFile), absRoot)
+	if err != nil {
+		return 0, err
+	}
+	saved := *snap
+	saved.Root = filepath.ToSlash(rel)
+
+	data, err := json.MarshalIndent(&saved, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	data = append(data, '\n')
+	if isDryRun() {
+		dryRunf("would save the snapshot of '%s' to '%s' (%d bytes)", snap.Root, file, len(data))
+		return 0, nil
+	}
+
+	before := journalCapture(file)
+	if err := atomicWriteFile(file, data, 0644); err != nil {
+		return 0, err
+	}
+	kind := journalModify
+	if !before.State.Exists {
+		kind = journalCreate
+	}
+	recordJournal(kind, "gxfingerprint", file, "", before)
+	return int64(len(data)), nil
+}
+
+// gxfingerprint prints a hash of each directory tree and, with --save,
+// writes a snapshot for gxchanged. Given several directories, it reports
+// whether they are identical.
+func gxfingerprint(opts fingerprintOptions) (int64, error) {
+	for _, dir := range append([]string{opts.save}, opts.dirs...) {
+		if dir != "" && !validatePath(dir) {
+			return 0, errInvalidInput
+		}
+	}
+	exclude := ""
+	if opts.save != "" {
+		exclude, _ = filepath.Abs(opts.save)
+	}
+
+	var first string
+	identical := true
+	var written int64
+	for i, dir := range opts.dirs {
+		snap, err := scanTree(dir, exclude, opts.jobs)
+		if err != nil {
+			fmt.Printf("Error fingerprinting '%s': %v\n", dir, err)
+			exitStatus = 1
+			return 0, err
+		}
+		files, dirs := countEntries(snap)
+		fmt.Printf("%s  %s  (%d file(s), %d dir(s))\n", snap.Fingerprint, dir, files, dirs)
+		if i == 0 {
+			first = snap.Fingerprint
+		} else if snap.Fingerprint != first {
+			identical = false
+		}
+
+		if opts.save != "" {
+			n, err := saveSnapshot(snap, opts.save)
+			if err != nil {
+				fmt.Printf("Error saving snapshot '%s': %v\n", opts.save, err)
+				return 0, err
+			}
+			if n > 0 {
+				fmt.Printf("✅ Snapshot saved to '%s' (check later with: gxchanged %s)\n", opts.save, opts.save)
+			}
+			written = n
+		}
+	}
+
+	if len(opts.dirs) > 1 {
+		if identical {
+			fmt.Println("✅ Trees are identical")
+		} else {
+			fmt.Println("❌ Trees differ")
+			exitStatus = 1
+		}
+	}
+	return written, nil
+}
+
+// loadSnapshot reads a snapshot written by gxfingerprint --save
+func loadSnapshot(file string) (*treeSnapshot, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var snap treeSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("not a gxfingerprint snapshot: %v", err)
+	}
+	if snap.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+	return &snap, nil
+}
+
+// describeChange explains how an entry changed, or returns "" if it did not.
+// A directory's own hash changes with its contents, which are reported
+// separately, so only its mode is compared.
+func describeChange(old, cur treeEntry) string {
+	switch {
+	case old.Type != cur.Type:
+		return fmt.Sprintf("%s -> %s", old.Type, cur.Type)
+	case old.Type == "file" && old.Hash != cur.Hash:
+		return fmt.Sprintf("contents, %s -> %s", formatBytes(old.Size), formatBytes(cur.Size))
+	case old.Type == "symlink" && old.Hash != cur.Hash:
+		return "link target"
+	case old.Mode != cur.Mode:
+		return fmt.Sprintf("mode %s -> %s", old.Mode, cur.Mode)
+	}
+	return ""
+}
+
+// gxchanged lists what was added, removed or modified in a tree since a
+// snapshot was saved, and sets a non-zero exit status when anything changed
+func gxchanged(args []string) {
+	file, root := args[0], ""
+	if len(args) > 1 {
+		root = args[1]
+	}
+	if !validatePath(file) || (root != "" && !validatePath(root)) {
+		exitStatus = 1
+		return
+	}
+
+	snap, err := loadSnapshot(file)
+	if err != nil {
+		fmt.Printf("Error reading snapshot '%s': %v\n", file, err)
+		exitStatus = 1
+		return
+	}
+	if root == "" {
+		root = filepath.Join(filepath.Dir(file), filepath.FromSlash(snap.Root))
+		if !validatePath(root) {
+			exitStatus = 1
+			return
+		}
+	}
+
+	exclude, _ := filepath.Abs(file)
+	cur, err := scanTree(root, exclude, runtime.NumCPU())
+	if err != nil {
+		fmt.Printf("Error fingerprinting '%s': %v\n", root, err)
+		exitStatus = 1
+		return
+	}
+	if cur.Fingerprint == snap.Fingerprint {
+		fmt.Printf("✅ No changes in '%s' since the snapshot of %s\n", root, snap.Created)
+		return
+	}
+
+	old := make(map[string]treeEntry, len(snap.Entries))
+	for _, e := range snap.Entries {
+		old[e.Path] = e
+	}
+	seen := make(map[string]bool, len(cur.Entries))
+	type change struct{ mark, path, detail string }
+	var changes []change
+	for _, e := range cur.Entries {
+		seen[e.Path] = true
+		prev, ok := old[e.Path]
+		if !ok {
+			changes = append(changes, change{"+", e.Path, ""})
+		} else if detail := describeChange(prev, e); detail != "" {
+			changes = append(changes, change{"M", e.Path, detail})
+		}
+	}
+	for _, e := range snap.Entries {
+		if !seen[e.Path] {
+			changes = append(changes, change{"-", e.Path, ""})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].path < changes[j].path })
+
+	counts := map[string]int{}
+	fmt.Printf("\n--- Changes in '%s' since %s ---\n", root, snap.Created)
+	for _, c := range changes {
+		counts[c.mark]++
+		if c.detail != "" {
+			fmt.Printf("%s %s (%s)\n", c.mark, c.path, c.detail)
+		} else {
+			fmt.Printf("%s %s\n", c.mark, c.path)
+		}
+	}
+	fmt.Printf("🔎 %d added, %d removed, %d modified\n", counts["+"], counts["-"], counts["M"])
+	exitStatus = 1
+}
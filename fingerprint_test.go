@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSubtreeHashStable(t *testing.T) {
+	entries := []treeEntry{
+		{Path: "b.txt", Type: "file", Mode: "0644", Size: 3, Hash: "11"},
+		{Path: "a.txt", Type: "file", Mode: "0644", Size: 3, Hash: "22"},
+		{Path: "sub", Type: "dir", Mode: "0755", Hash: "33"},
+	}
+	want := subtreeHash(entries, []int{0, 1, 2})
+	// Saved snapshots are compared against this, so the format must not drift
+	if want != "f8e7880c936a0aee64fd9e4731d5761206181403b5f6b5f44d3a418dbf828f47" {
+		t.Errorf("subtreeHash = %s, which no longer matches saved snapshots", want)
+	}
+	for _, order := range [][]int{{2, 1, 0}, {1, 0, 2}, {2, 0, 1}} {
+		if got := subtreeHash(entries, order); got != want {
+			t.Errorf("subtreeHash depends on child order %v: %s != %s", order, got, want)
+		}
+	}
+
+	changes := map[string]func(e *treeEntry){
+		"mode":      func(e *treeEntry) { e.Mode = "0600" },
+		"name":      func(e *treeEntry) { e.Path = "c.txt" },
+		"type":      func(e *treeEntry) { e.Type = "symlink" },
+		"contents":  func(e *treeEntry) { e.Hash = "44" },
+		"directory": func(e *treeEntry) { e.Path = "dir/b.txt" },
+	}
+	for name, change := range changes {
+		changed := append([]treeEntry(nil), entries...)
+		change(&changed[0])
+		if name == "directory" {
+			// Only the base name counts; the parent is covered by its own hash
+			if got := subtreeHash(changed, []int{0, 1, 2}); got != want {
+				t.Errorf("subtreeHash changed with the parent directory")
+			}
+			continue
+		}
+		if got := subtreeHash(changed, []int{0, 1, 2}); got == want {
+			t.Errorf("subtreeHash unchanged after changing the %s", name)
+		}
+	}
+}
+
+func TestScanTreeFingerprint(t *testing.T) {
+	write := func(root string) {
+		os.MkdirAll(filepath.Join(root, "sub", "deep"), 0755)
+		os.WriteFile(filepath.Join(root, "a.txt"), []byte("alpha"), 0644)
+		os.WriteFile(filepath.Join(root, "sub", "deep", "b.txt"), []byte("beta"), 0644)
+	}
+	dir := t.TempDir()
+	one, two := filepath.Join(dir, "one"), filepath.Join(dir, "two")
+	write(one)
+	write(two)
+
+	first, err := scanTree(one, "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := scanTree(one, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied, err := scanTree(two, "", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Fingerprint != again.Fingerprint || first.Fingerprint != copied.Fingerprint {
+		t.Errorf("fingerprints differ: %s %s %s", first.Fingerprint, again.Fingerprint, copied.Fingerprint)
+	}
+
+	os.WriteFile(filepath.Join(two, "sub", "deep", "b.txt"), []byte("BETA"), 0644)
+	changed, err := scanTree(two, "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed.Fingerprint == first.Fingerprint {
+		t.Error("fingerprint unchanged after editing a nested file")
+	}
+	for _, e := range changed.Entries {
+		if e.Path == "a.txt" && e.Hash != first.Entries[0].Hash {
+			t.Error("an unchanged file's hash changed")
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ==================== FUZZY FINDER ====================
+
+// Scoring weights for fuzzy matches. Every matched character earns
+// fuzzyMatch; characters that follow the previous match or start a word earn
+// more, gaps between matches cost a little, and matches that fall entirely
+// within the base name get a bonus so "main" ranks src/main.go above
+// maintenance/notes.txt.
+const (
+	fuzzyMatch       = 16
+	fuzzyConsecutive = 24
+	fuzzyBoundary    = 20
+	fuzzySeparator   = 24 // first character after a '/'
+	fuzzyGapStart    = -3
+	fuzzyGapExtend   = -1
+	fuzzyBasename    = 40
+)
+
+// fuzzyOptions holds the parsed gxfzf arguments
+type fuzzyOptions struct {
+	query   string
+	limit   int
+	filter  walkFilter
+	noIndex bool
+	action  string // gx command to run on the picked result, or ""
+}
+
+// fuzzyResult is a ranked candidate
+type fuzzyResult struct {
+	path      string
+	score     int
+	positions []int // rune indexes of the matched characters
+}
+
+// parseFuzzyArgs parses "gxfzf [-n N] [--dirs] [--hidden] [--cat|--cd] query"
+func parseFuzzyArgs(args []string) (fuzzyOptions, error) {
+	opts := fuzzyOptions{limit: 10}
+	var words []string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "-n":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("missing count for -n")
+			}
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				return opts, fmt.Errorf("invalid count '%s'", args[i])
+			}
+			opts.limit = n
+		case "--dirs":
+			opts.filter.dirs = true
+		case "--hidden":
+			opts.filter.hidden = true
+		case "--no-ignore":
+			opts.filter.noIgnore = true
+		case "--no-index":
+			opts.noIndex = true
+		case "--cat":
+			opts.action = "gxcat"
+		case "--cd":
+			opts.action = "gxc"
+			opts.filter.dirs = true
+		default:
+			words = append(words, arg)
+		}
+	}
+	if len(words) == 0 {
+		return opts, fmt.Errorf("missing search pattern")
+	}
+	// Spaces are not significant in a fuzzy query
+	opts.query = strings.Join(words, "")
+	return opts, nil
+}
+
+// isWordBoundary reports whether position i of s starts a word
+func isWordBoundary(s []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, cur := s[i-1], s[i]
+	switch prev {
+	case '/', '\\', '_', '-', '.', ' ':
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(cur)
+}
+
+// fuzzyMatchFrom matches query as a subsequence of target starting at start.
+// It finds the first complete match, then scans backwards from its end to
+// pick the shortest window, and returns the matched positions or nil.
+func fuzzyMatchFrom(query, target []rune, start int) []int {
+	qi, end := 0, -1
+	for i := start; i < len(target) && qi < len(query); i++ {
+		if target[i] == query[qi] {
+			qi++
+			if qi == len(query) {
+				end = i
+			}
+		}
+	}
+	if end < 0 {
+		return nil
+	}
+
+	qi = len(query) - 1
+	begin := end
+	for i := end; i >= start && qi >= 0; i-- {
+		if target[i] == query[qi] {
+			qi--
+			begin = i
+		}
+	}
+
+	positions := make([]int, 0, len(query))
+	qi = 0
+	for i := begin; i <= end && qi < len(query); i++ {
+		if target[i] == query[qi] {
+			positions = append(positions, i)
+			qi++
+		}
+	}
+	return positions
+}
+
+// fuzzyScore scores path against the query. Matching is case-insensitive
+// unless the query contains an upper-case letter.
+func fuzzyScore(query, path string) (fuzzyResult, bool) {
+	caseSensitive := strings.IndexFunc(query, unicode.IsUpper) >= 0
+	original := []rune(filepath.ToSlash(path))
+	target := original
+	q := []rune(query)
+	if !caseSensitive {
+		target = []rune(strings.ToLower(string(original)))
+		q = []rune(strings.ToLower(query))
+	}
+
+	base := 0
+	for i, r := range target {
+		if r == '/' {
+			base = i + 1
+		}
+	}
+
+	score := 0
+	positions := fuzzyMatchFrom(q, target, base)
+	if positions != nil {
+		score += fuzzyBasename
+	} else if positions = fuzzyMatchFrom(q, target, 0); positions == nil {
+		return fuzzyResult{}, false
+	}
+
+	for n, pos := range positions {
+		score += fuzzyMatch
+		if isWordBoundary(original, pos) {
+			if pos > 0 && original[pos-1] == '/' {
+				score += fuzzySeparator
+			} else {
+				score += fuzzyBoundary
+			}
+		}
+		if n > 0 {
+			if gap := pos - positions[n-1] - 1; gap == 0 {
+				score += fuzzyConsecutive
+			} else {
+				score += fuzzyGapStart + fuzzyGapExtend*(gap-1)
+			}
+		}
+	}
+	return fuzzyResult{path: path, score: score, positions: positions}, true
+}
+
+// fuzzyRank scores every candidate and returns the best matches, highest
+// score first; ties go to the shorter path, then alphabetical order
+func fuzzyRank(query string, candidates []string, limit int) ([]fuzzyResult, int) {
+	var results []fuzzyResult
+	for _, path := range candidates {
+		if result, ok := fuzzyScore(query, path); ok {
+			results = append(results, result)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if len(a.path) != len(b.path) {
+			return len(a.path) < len(b.path)
+		}
+		return a.path < b.path
+	})
+	total := len(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, total
+}
+
+// fuzzyCandidates lists the paths under the current directory to match
+// against, from the gxindex index when one covers it
+func fuzzyCandidates(filter walkFilter, useIndex bool) []string {
+	if useIndex {
+		if idx := findIndex("."); idx != nil {
+			indexNotice(idx)
+			return indexCandidates(idx, filter)
+		}
+	}
+	var paths []string
+	walkFiltered(".", filter, func(path string) {
+		paths = append(paths, path)
+	}, func(error) {})
+	return paths
+}
+
+// highlightPositions renders path with the matched characters highlighted
+func highlightPositions(path string, positions []int) string {
+	if !colorsEnabled() {
+		return path
+	}
+	matched := make(map[int]bool, len(positions))
+	for _, pos := range positions {
+		matched[pos] = true
+	}
+	var b strings.Builder
+	for i, r := range []rune(path) {
+		if matched[i] {
+			b.WriteString(paint(tokMatch, string(r)))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// pickResult asks the user to choose one of the listed results
+func pickResult(results []fuzzyResult) (string, bool) {
+	if len(results) == 1 {
+		return results[0].path, true
+	}
+	for {
+		fmt.Fprintf(promptOut(), "Select 1-%d (Enter to cancel): ", len(results))
+		if !inputScanner.Scan() {
+			fmt.Fprintln(promptOut())
+			return "", false
+		}
+		answer := strings.TrimSpace(inputScanner.Text())
+		if answer == "" {
+			return "", false
+		}
+		n, err := strconv.Atoi(answer)
+		if err == nil && n >= 1 && n <= len(results) {
+			return results[n-1].path, true
+		}
+		fmt.Fprintln(promptOut(), "❌ Invalid selection")
+	}
+}
+
+// gxfzf ranks files under the current directory by how well they fuzzy-match
+// the query and optionally opens the chosen one with gxcat or gxc
+func gxfzf(opts fuzzyOptions) {
+	if !validateSearchTerm(opts.query) {
+		return
+	}
+
+	results, total := fuzzyRank(opts.query, fuzzyCandidates(opts.filter, !opts.noIndex), opts.limit)
+	if len(results) == 0 {
+		fmt.Printf("No files match '%s'\n", opts.query)
+		return
+	}
+
+	fmt.Printf("\n--- Best matches for '%s' (%d of %d) ---\n", opts.query, len(results), total)
+	for i, result := range results {
+		suffix := ""
+		if info, err := os.Stat(result.path); err == nil && info.IsDir() {
+			suffix = "/"
+		}
+		fmt.Printf("%3d. %s%s\n", i+1, highlightPositions(result.path, result.positions), suffix)
+	}
+
+	if opts.action == "" {
+		return
+	}
+	path, ok := pickResult(results)
+	if !ok {
+		return
+	}
+	if opts.action == "gxc" {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			path = filepath.Dir(path)
+		}
+	}
+	handleCommand(opts.action, []string{opts.action, path})
+}
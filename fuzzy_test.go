@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyRankOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		candidates []string
+		want       []string
+	}{
+		{"basename beats directory", "main",
+			[]string{"maintenance/notes.txt", "src/main.go"},
+			[]string{"src/main.go", "maintenance/notes.txt"}},
+		{"consecutive beats scattered", "conf",
+			[]string{"cmd/o_n_f.go", "config.go"},
+			[]string{"config.go", "cmd/o_n_f.go"}},
+		{"word starts beat inner letters", "fb",
+			[]string{"xfxb.go", "foo_bar.go"},
+			[]string{"foo_bar.go", "xfxb.go"}},
+		{"camel case boundaries", "gs",
+			[]string{"bugs.go", "getSize.go"},
+			[]string{"getSize.go", "bugs.go"}},
+		{"after a slash beats the start", "a",
+			[]string{"a", "b/a"},
+			[]string{"b/a", "a"}},
+		{"ties go to the shorter path", "a",
+			[]string{"xxa", "xa"},
+			[]string{"xa", "xxa"}},
+		{"non-matches dropped", "xyz",
+			[]string{"x.go", "xyz.go", "zyx.go"},
+			[]string{"xyz.go"}},
+		{"upper case query is case-sensitive", "Read",
+			[]string{"readme.md", "README.md", "Reader.go"},
+			[]string{"Reader.go"}},
+	}
+	for _, tt := range tests {
+		results, total := fuzzyRank(tt.query, tt.candidates, 10)
+		var got []string
+		for _, r := range results {
+			got = append(got, r.path)
+		}
+		if !reflect.DeepEqual(got, tt.want) || total != len(tt.want) {
+			t.Errorf("%s: fuzzyRank(%q) = %q (%d), want %q", tt.name, tt.query, got, total, tt.want)
+		}
+	}
+}
+
+func TestFuzzyMatchPositions(t *testing.T) {
+	tests := []struct {
+		query, path string
+		want        []int
+	}{
+		{"abc", "abc", []int{0, 1, 2}},
+		// The shortest window is chosen, not the first 'a'
+		{"ab", "a_xab", []int{3, 4}},
+		{"mg", "src/main.go", []int{4, 9}},
+		{"sm", "src/main.go", []int{0, 4}},
+	}
+	for _, tt := range tests {
+		result, ok := fuzzyScore(tt.query, tt.path)
+		if !ok || !reflect.DeepEqual(result.positions, tt.want) {
+			t.Errorf("fuzzyScore(%q, %q) positions = %v, want %v", tt.query, tt.path, result.positions, tt.want)
+		}
+	}
+}
+
+func TestFuzzyRankLimit(t *testing.T) {
+	results, total := fuzzyRank("a", []string{"a1", "a2", "a3"}, 2)
+	if len(results) != 2 || total != 3 {
+		t.Errorf("fuzzyRank with limit 2 = %d results of %d, want 2 of 3", len(results), total)
+	}
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// ==================== SEARCH ====================
+
+// grepOptions holds the parsed gxgrep arguments
+type grepOptions struct {
+	pattern       string
+	regex         bool
+	caseSensitive bool
+	wholeWord     bool
+	invert        bool
+	before        int
+	after         int
+	countOnly     bool
+	filesOnly     bool
+	recursive     bool
+	color         bool
+	jobs          int
+	filter        walkFilter
+	paths         []string
+	re            *regexp.Regexp
+}
+
+// parseGrepArgs parses "gxgrep [options] pattern [files/dirs...]"
+func parseGrepArgs(args []string) (*grepOptions, error) {
+	opts := &grepOptions{color: colorsEnabled()}
+	var rest []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i+1:]...)
+			break
+		}
+		switch arg {
+		case "-e", "--regex":
+			opts.regex = true
+		case "-s", "--case-sensitive":
+			opts.caseSensitive = true
+		case "-i", "--ignore-case":
+			opts.caseSensitive = false
+		case "-w", "--word":
+			opts.wholeWord = true
+		case "-v", "--invert":
+			opts.invert = true
+		case "-c", "--count":
+			opts.countOnly = true
+		case "-l", "--files":
+			opts.filesOnly = true
+		case "-r", "-R", "--recursive":
+			opts.recursive = true
+		case "--hidden":
+			opts.filter.hidden = true
+		case "--no-ignore":
+			opts.filter.noIgnore = true
+		case "--color", "--color=always":
+			opts.color = true
+		case "--no-color", "--color=never":
+			opts.color = false
+		case "-j", "--jobs":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("missing worker count for %s", arg)
+			}
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid worker count '%s'", args[i])
+			}
+			opts.jobs = n
+		case "-A", "-B", "-C":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("missing line count for %s", arg)
+			}
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid line count '%s' for %s", args[i], arg)
+			}
+			if arg != "-B" {
+				opts.after = n
+			}
+			if arg != "-A" {
+				opts.before = n
+			}
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	if len(rest) == 0 {
+		return nil, fmt.Errorf("missing search pattern")
+	}
+	opts.pattern, opts.paths = rest[0], rest[1:]
+	if len(opts.paths) == 0 {
+		if !opts.recursive {
+			return nil, fmt.Errorf("missing filename (or use -r to search the current directory)")
+		}
+		opts.paths = []string{"."}
+	}
+
+	pattern := opts.pattern
+	if !opts.regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if opts.wholeWord {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	if !opts.caseSensitive {
+		pattern = `(?i)` + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern '%s': %v", opts.pattern, err)
+	}
+	opts.re = re
+	return opts, nil
+}
+
+// highlight wraps every match in the line with color codes
+func (o *grepOptions) highlight(line string) string {
+	if !o.color || o.invert {
+		return line
+	}
+	return o.re.ReplaceAllStringFunc(line, func(m string) string {
+		return paint(tokMatch, m)
+	})
+}
+
+// grepLine is a line kept for context output
+type grepLine struct {
+	num  int
+	text string
+}
+
+// grepStream searches r and writes results for the named file to w.
+// It returns the number of matching (or, with -v, non-matching) lines.
+func grepStream(r io.Reader, name string, opts *grepOptions, multi bool, w io.Writer) (int, error) {
+	prefix := func(num int, sep string) string {
+		if !multi {
+			return fmt.Sprintf("  Line %d%s ", num, sep)
+		}
+		file := name
+		if opts.color {
+			file = paint(tokFile, name)
+		}
+		return fmt.Sprintf("%s:%d%s ", file, num, sep)
+	}
+
+	quiet := opts.countOnly || opts.filesOnly
+	var pending []grepLine // lines kept for -B context
+	afterLeft := 0
+	lastPrinted := 0
+	lineNum := 0
+	count := 0
+
+	err := forEachLine(r, func(line, _ string) error {
+		lineNum++
+		selected := opts.re.MatchString(line) != opts.invert
+		if !selected {
+			if afterLeft > 0 && !quiet {
+				fmt.Fprintf(w, "%s%s\n", prefix(lineNum, "-"), line)
+				lastPrinted = lineNum
+				afterLeft--
+			} else if opts.before > 0 {
+				pending = append(pending, grepLine{lineNum, line})
+				if len(pending) > opts.before {
+					pending = pending[1:]
+				}
+			}
+			return nil
+		}
+
+		count++
+		if quiet {
+			return nil
+		}
+		if (opts.before > 0 || opts.after > 0) && lastPrinted > 0 {
+			first := lineNum
+			if len(pending) > 0 {
+				first = pending[0].num
+			}
+			if first > lastPrinted+1 {
+				fmt.Fprintln(w, "--")
+			}
+		}
+		for _, ctx := range pending {
+			fmt.Fprintf(w, "%s%s\n", prefix(ctx.num, "-"), ctx.text)
+		}
+		pending = pending[:0]
+		fmt.Fprintf(w, "%s%s\n", prefix(lineNum, ":"), opts.highlight(line))
+		lastPrinted = lineNum
+		afterLeft = opts.after
+		return nil
+	})
+
+	if err == nil && opts.countOnly {
+		fmt.Fprintf(w, "%s: %d\n", name, count)
+	} else if err == nil && opts.filesOnly && count > 0 {
+		fmt.Fprintln(w, name)
+	}
+	return count, err
+}
+
+// grepResult is the buffered output of searching one file
+type grepResult struct {
+	path     string
+	output   bytes.Buffer
+	count    int
+	binary   bool
+	explicit bool // named on the command line rather than found by -r
+	err      error
+}
+
+// grepJob asks a worker to search one file and deliver the result on out
+type grepJob struct {
+	path     string
+	explicit bool
+	out      chan *grepResult
+}
+
+// searchFile searches a single file into a buffer, skipping binary files
+func searchFile(job grepJob, opts *grepOptions, multi bool) *grepResult {
+	res := &grepResult{path: job.path, explicit: job.explicit}
+	file, err := os.Open(job.path)
+	if err != nil {
+		res.err = err
+		return res
+	}
+	defer file.Close()
+
+	head := make([]byte, binarySniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		res.err = err
+		return res
+	}
+	head = head[:n]
+	enc := detectEncoding(head)
+	if isBinaryText(head, enc) {
+		res.binary = true
+		return res
+	}
+
+	// UTF-16 and Latin-1 files are searched as the text they contain
+	r := newDecodeReader(io.MultiReader(bytes.NewReader(head), file), enc)
+	res.count, res.err = grepStream(r, job.path, opts, multi, &res.output)
+	return res
+}
+
+// grepRoot is a command-line path after glob expansion
+type grepRoot struct {
+	path  string
+	isDir bool
+}
+
+// grepRoots expands the path arguments into files and directories
+func grepRoots(opts *grepOptions) ([]grepRoot, error) {
+	var roots []grepRoot
+	for _, path := range opts.paths {
+		if !validatePath(path) {
+			return nil, errInvalidInput
+		}
+		matches, err := filepath.Glob(path)
+		if err != nil || matches == nil {
+			matches = []string{path}
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			roots = append(roots, grepRoot{match, info.IsDir()})
+		}
+	}
+	return roots, nil
+}
+
+// grepFile searches files for a literal string or regular expression.
+// Matching is case-insensitive unless -s is given. Files are searched by a
+// pool of workers; each result is buffered and printed in walk order so the
+// output is the same on every run.
+func grepFile(opts *grepOptions) {
+	if !validateSearchTerm(opts.pattern) {
+		return
+	}
+
+	roots, err := grepRoots(opts)
+	if err != nil {
+		if err != errInvalidInput {
+			fmt.Println("Error:", err)
+		}
+		return
+	}
+
+	multi := len(roots) > 1 || opts.recursive
+	quiet := opts.countOnly || opts.filesOnly
+	if !multi && !quiet && !roots[0].isDir {
+		fmt.Printf("\n--- Searching for '%s' in %s ---\n", opts.pattern, roots[0].path)
+	}
+
+	workers := opts.jobs
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	jobs := make(chan grepJob)
+	order := make(chan chan *grepResult, workers*4)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				job.out <- searchFile(job, opts, multi)
+			}
+		}()
+	}
+
+	// The walker reserves a slot in order before handing the job to a worker,
+	// so the printer below always waits on results in walk order
+	go func() {
+		defer close(order)
+		defer close(jobs)
+		seen := make(map[string]bool)
+		emit := func(path string, explicit bool) {
+			if seen[filepath.Clean(path)] {
+				return
+			}
+			seen[filepath.Clean(path)] = true
+			out := make(chan *grepResult, 1)
+			order <- out
+			jobs <- grepJob{path, explicit, out}
+		}
+		fail := func(err error) {
+			out := make(chan *grepResult, 1)
+			out <- &grepResult{err: err}
+			order <- out
+		}
+		for _, root := range roots {
+			if !root.isDir {
+				emit(root.path, true)
+			} else if !opts.recursive {
+				fail(fmt.Errorf("'%s' is a directory (use -r to search it)", root.path))
+			} else {
+				walkFiltered(root.path, opts.filter, func(path string) { emit(path, false) }, fail)
+			}
+		}
+	}()
+
+	found, matchedFiles, searched, binaries := 0, 0, 0, 0
+	for out := range order {
+		res := <-out
+		switch {
+		case res.err != nil && res.path == "":
+			fmt.Println("⚠️ ", res.err)
+		case res.err != nil:
+			fmt.Printf("Error reading file '%s': %v\n", res.path, res.err)
+		case res.binary:
+			binaries++
+			if res.explicit {
+				fmt.Printf("⚠️  Skipping binary file '%s'\n", res.path)
+			}
+		default:
+			searched++
+			os.Stdout.Write(res.output.Bytes())
+			found += res.count
+			if res.count > 0 {
+				matchedFiles++
+			}
+		}
+	}
+	wg.Wait()
+
+	if quiet || searched == 0 {
+		return
+	}
+	if found == 0 {
+		fmt.Printf("No matches found for '%s'\n", opts.pattern)
+	} else if multi {
+		fmt.Printf("--- Found %d match(es) in %d of %d file(s) ---\n", found, matchedFiles, searched)
+	} else {
+		fmt.Printf("--- Found %d match(es) ---\n", found)
+	}
+	if binaries > 0 && multi {
+		fmt.Printf("(%d binary file(s) skipped)\n", binaries)
+	}
+}
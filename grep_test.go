@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGrepPattern(t *testing.T) {
+	tests := []struct {
+		args []string
+		line string
+		want bool
+	}{
+		{[]string{"a.b", "f"}, "xa.by", true},
+		{[]string{"a.b", "f"}, "axb", false}, // literal by default
+		{[]string{"-e", "a.b", "f"}, "axb", true},
+		{[]string{"error", "f"}, "ERROR: disk", true}, // case-insensitive by default
+		{[]string{"-s", "error", "f"}, "ERROR: disk", false},
+		{[]string{"-w", "cat", "f"}, "the cat sat", true},
+		{[]string{"-w", "cat", "f"}, "concatenate", false},
+		{[]string{"-e", "-w", "a|b", "f"}, "xa b", true},
+		{[]string{"--", "-y", "f"}, "say -y", true},
+	}
+	for _, tt := range tests {
+		opts, err := parseGrepArgs(append([]string{"--no-color"}, tt.args...))
+		if err != nil {
+			t.Errorf("parseGrepArgs(%q): %v", tt.args, err)
+			continue
+		}
+		if got := opts.re.MatchString(tt.line); got != tt.want {
+			t.Errorf("%q matching %q = %v, want %v", tt.args, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestGrepStreamContext(t *testing.T) {
+	input := "a\nmatch 1\nb\nc\nd\ne\nmatch 2\nf\n"
+	tests := []struct {
+		name  string
+		args  []string
+		want  string
+		count int
+	}{
+		{"plain", []string{"match"}, "  Line 2: match 1\n  Line 7: match 2\n", 2},
+		{"after", []string{"-A", "1", "match"}, "  Line 2: match 1\n  Line 3- b\n--\n  Line 7: match 2\n  Line 8- f\n", 2},
+		{"before", []string{"-B", "1", "match"}, "  Line 1- a\n  Line 2: match 1\n--\n  Line 6- e\n  Line 7: match 2\n", 2},
+		{"joined", []string{"-C", "2", "match"}, "  Line 1- a\n  Line 2: match 1\n  Line 3- b\n  Line 4- c\n  Line 5- d\n  Line 6- e\n  Line 7: match 2\n  Line 8- f\n", 2},
+		{"invert", []string{"-v", "-e", "^[a-e]$"}, "  Line 2: match 1\n  Line 7: match 2\n  Line 8: f\n", 3},
+		{"count", []string{"-c", "match"}, "f: 2\n", 2},
+	}
+	for _, tt := range tests {
+		opts, err := parseGrepArgs(append(append([]string{"--no-color"}, tt.args...), "f"))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var out strings.Builder
+		count, err := grepStream(strings.NewReader(input), "f", opts, false, &out)
+		if err != nil || count != tt.count || out.String() != tt.want {
+			t.Errorf("%s: got %d, %v,\n%q\nwant %d,\n%q", tt.name, count, err, out.String(), tt.count, tt.want)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// ==================== HASHING ====================
+
+// hashAlgorithm describes one algorithm supported by gxhash
+type hashAlgorithm struct {
+	tag string // name used by --tag output, as the coreutils tools print it
+	new func() hash.Hash
+}
+
+var hashAlgorithms = map[string]hashAlgorithm{
+	"md5":    {"MD5", md5.New},
+	"sha1":   {"SHA1", sha1.New},
+	"sha256": {"SHA256", sha256.New},
+	"sha512": {"SHA512", sha512.New},
+	"blake2b": {"BLAKE2b", func() hash.Hash {
+		h, _ := blake2b.New512(nil)
+		return h
+	}},
+	"crc32": {"CRC32", func() hash.Hash { return crc32.NewIEEE() }},
+}
+
+// hashAlgorithmNames lists the supported algorithms for messages
+func hashAlgorithmNames() string {
+	names := make([]string, 0, len(hashAlgorithms))
+	for name := range hashAlgorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, "|")
+}
+
+// hashOptions holds the parsed gxhash arguments
+type hashOptions struct {
+	algorithm string
+	tag       bool // print "SHA256 (file) = hash" instead of "hash  file"
+	recursive bool // hash every file under directories
+	jobs      int
+	patterns  []string
+}
+
+// parseHashArgs parses "gxhash [-a ALG] [--tag] [-r] [-j N] file|glob|dir...".
+// gxmd5 and gxsha1 are gxhash with their algorithm and --tag preset.
+func parseHashArgs(command string, args []string) (hashOptions, error) {
+	opts := hashOptions{algorithm: "sha256", jobs: runtime.NumCPU()}
+	switch command {
+	case "gxmd5":
+		opts.algorithm, opts.tag = "md5", true
+	case "gxsha1":
+		opts.algorithm, opts.tag = "sha1", true
+	}
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "-a", "-j":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("missing value for %s", arg)
+			}
+			i++
+			if arg == "-a" {
+				opts.algorithm = strings.ToLower(args[i])
+				break
+			}
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				return opts, fmt.Errorf("invalid job count '%s'", args[i])
+			}
+			opts.jobs = n
+		case "--tag":
+			opts.tag = true
+		case "-r", "--recursive":
+			opts.recursive = true
+		case "--":
+			opts.patterns = append(opts.patterns, args[i+1:]...)
+			i = len(args)
+		default:
+			opts.patterns = append(opts.patterns, arg)
+		}
+	}
+	if _, ok := hashAlgorithms[opts.algorithm]; !ok {
+		return opts, fmt.Errorf("unknown algorithm '%s' (use %s)", opts.algorithm, hashAlgorithmNames())
+	}
+	return opts, nil
+}
+
+// escapeChecksumName escapes a backslash, newline or carriage return in a
+// name the way coreutils does; escaped reports whether the name changed
+func escapeChecksumName(name string) (string, bool) {
+	if !strings.ContainsAny(name, "\\\n\r") {
+		return name, false
+	}
+	return strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r").Replace(name), true
+}
+
+// formatHashLine renders one result the way sha256sum and friends do. Names
+// containing a backslash or newline are escaped and the line starts with a
+// backslash, as coreutils does, so the output can be read back by gxverify.
+func formatHashLine(sum, name string, opts hashOptions) string {
+	name, escaped := escapeChecksumName(name)
+	line := sum + "  " + name
+	if opts.tag {
+		line = fmt.Sprintf("%s (%s) = %s", hashAlgorithms[opts.algorithm].tag, name, sum)
+	}
+	if escaped {
+		line = "\\" + line
+	}
+	return line
+}
+
+// hashReader returns the hex digest of everything read from r
+func hashReader(r io.Reader, algorithm string) (string, error) {
+	h := hashAlgorithms[algorithm].new()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashFile returns the hex digest of a file's contents
+func hashFile(path, algorithm string, prog *progress) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return hashReader(prog.reader(file), algorithm)
+}
+
+// hashResult is the outcome of hashing one file
+type hashResult struct {
+	sum string
+	err error
+}
+
+// hashParallel runs hash for items 0..n-1 on up to jobs workers. Each item
+// gets its own result channel so callers can print in the input order
+// however the workers finish.
+func hashParallel(n, jobs int, hash func(i int) (string, error)) []chan hashResult {
+	results := make([]chan hashResult, n)
+	for i := range results {
+		results[i] = make(chan hashResult, 1)
+	}
+	items := make(chan int)
+	for w := 0; w < min(jobs, n); w++ {
+		go func() {
+			for i := range items {
+				sum, err := hash(i)
+				results[i] <- hashResult{sum, err}
+			}
+		}()
+	}
+	go func() {
+		for i := 0; i < n; i++ {
+			items <- i
+		}
+		close(items)
+	}()
+	return results
+}
+
+// totalSize adds up the sizes of the files that exist, for progress
+func totalSize(files []string) int64 {
+	var total int64
+	for _, path := range files {
+		if info, err := os.Stat(path); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// hashFiles hashes files in parallel and prints one line per file in the
+// order given. Errors go to standard error so the output stays a valid
+// checksum list. It returns the number of files that could not be hashed.
+func hashFiles(opts hashOptions, files []string) int {
+	prog := newProgress("Hashing", totalSize(files), int64(len(files)))
+	defer prog.finish()
+
+	results := hashParallel(len(files), opts.jobs, func(i int) (string, error) {
+		defer prog.addFile()
+		return hashFile(files[i], opts.algorithm, prog)
+	})
+
+	failed := 0
+	for i, path := range files {
+		res := <-results[i]
+		if res.err != nil {
+			prog.pause()
+			fmt.Fprintf(os.Stderr, "gxhash: %s: %v\n", path, res.err)
+			failed++
+			continue
+		}
+		prog.printf("%s\n", formatHashLine(res.sum, path, opts))
+	}
+	return failed
+}
+
+// collectHashFiles expands the gxhash arguments into the files to hash. With
+// -r, directories are walked in name order and every regular file in them is
+// included, except the file the output is being redirected to.
+func collectHashFiles(opts hashOptions) ([]string, error) {
+	if !opts.recursive {
+		return expandFileArgs(opts.patterns)
+	}
+	var files []string
+	for _, pattern := range opts.patterns {
+		if !validatePath(pattern) {
+			return nil, errInvalidInput
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %v", pattern, err)
+		}
+		if matches == nil {
+			matches = []string{pattern}
+		}
+		for _, match := range matches {
+			err := filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.Type().IsRegular() && !isRedirectTarget(path) {
+					files = append(files, path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
+// gxhash prints checksums of the given files
+func gxhash(opts hashOptions, files []string) {
+	if len(files) == 0 {
+		fmt.Println("No files to hash")
+		return
+	}
+	if failed := hashFiles(opts, files); failed > 0 {
+		fmt.Fprintf(os.Stderr, "gxhash: %d of %d file(s) could not be read\n", failed, len(files))
+		exitStatus = 1
+	}
+}
+
+// hashPipe hashes the output of another command ("gxcat a.txt | gxhash")
+// as it is produced; like sha256sum reading standard input, the name is "-"
+func hashPipe(parts, args []string) {
+	opts, err := parseHashArgs("gxhash", args)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	if len(opts.patterns) > 0 {
+		fmt.Println("Error: gxhash takes no file names when reading a pipe")
+		return
+	}
+
+	var sum string
+	var hashErr error
+	err = captureOutput(parts, func(r io.Reader) {
+		sum, hashErr = hashReader(r, opts.algorithm)
+	})
+	if err == nil {
+		err = hashErr
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+		exitStatus = 1
+		return
+	}
+	fmt.Println(formatHashLine(sum, "-", opts))
+}
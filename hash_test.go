@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatHashLine(t *testing.T) {
+	sum := "ab12"
+	tests := []struct {
+		name string
+		file string
+		tag  bool
+		want string
+	}{
+		{"plain", "file.txt", false, "ab12  file.txt"},
+		{"tagged", "file.txt", true, "SHA256 (file.txt) = ab12"},
+		{"spaces kept", "my file.txt", false, "ab12  my file.txt"},
+		{"newline escaped", "a\nb", false, `\ab12  a\nb`},
+		{"backslash escaped", `a\b`, false, `\ab12  a\\b`},
+		{"carriage return escaped", "a\rb", false, `\ab12  a\rb`},
+		{"tagged and escaped", "a\nb", true, `\SHA256 (a\nb) = ab12`},
+	}
+	for _, tt := range tests {
+		got := formatHashLine(sum, tt.file, hashOptions{algorithm: "sha256", tag: tt.tag})
+		if got != tt.want {
+			t.Errorf("%s: formatHashLine = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHashReader(t *testing.T) {
+	tests := []struct {
+		algorithm, want string
+	}{
+		{"md5", "900150983cd24fb0d6963f7d28e17f72"},
+		{"sha1", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{"sha256", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"crc32", "352441c2"},
+	}
+	for _, tt := range tests {
+		got, err := hashReader(strings.NewReader("abc"), tt.algorithm)
+		if err != nil || got != tt.want {
+			t.Errorf("hashReader(abc, %s) = %s, %v; want %s", tt.algorithm, got, err, tt.want)
+		}
+	}
+}
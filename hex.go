@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"unicode/utf8"
+)
+
+// ==================== BINARY FILES & HEX DUMP ====================
+
+// binarySniffLen is how much of a file is checked when deciding whether it is binary
+const binarySniffLen = 8000
+
+// binaryInvalidRatio is the share of invalid UTF-8 or control bytes above
+// which data is treated as binary even without a NUL byte
+const binaryInvalidRatio = 0.1
+
+// hexRowWidth is the number of bytes shown per gxhex row
+const hexRowWidth = 16
+
+// gxcatHexPreview is how much of a binary file gxcat shows as a hex dump
+const gxcatHexPreview = 256
+
+// isBinary reports whether data looks like binary content: it contains a NUL
+// byte, or too much of it is invalid UTF-8 or non-text control characters
+func isBinary(data []byte) bool {
+	if bytes.IndexByte(data, 0) >= 0 {
+		return true
+	}
+	bad := 0
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			// A rune cut off by the end of the sample is not evidence
+			if !utf8.FullRune(data[i:]) {
+				break
+			}
+			bad++
+		} else if r < 0x20 && r != '\n' && r != '\r' && r != '\t' && r != '\f' && r != '\b' && r != 0x1b {
+			bad++
+		}
+		i += size
+	}
+	return len(data) > 0 && float64(bad)/float64(len(data)) > binaryInvalidRatio
+}
+
+// hexOptions holds the parsed gxhex arguments
+type hexOptions struct {
+	offset  int64 // start offset; negative counts back from the end
+	length  int64 // bytes to show (-1 for all)
+	diff    bool  // compare two files byte by byte
+	verbose bool  // do not collapse repeated rows
+	files   []string
+}
+
+// parseHexArgs parses "gxhex [-s OFFSET] [-n LENGTH] [-v] [-d] file [file2]".
+// Offsets and lengths accept decimal or 0x-prefixed hex.
+func parseHexArgs(args []string) (hexOptions, error) {
+	opts := hexOptions{length: -1}
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "-s", "-n":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("missing value for %s", arg)
+			}
+			i++
+			n, err := strconv.ParseInt(args[i], 0, 64)
+			if err != nil || (arg == "-n" && n < 0) {
+				return opts, fmt.Errorf("invalid value '%s' for %s", args[i], arg)
+			}
+			if arg == "-s" {
+				opts.offset = n
+			} else {
+				opts.length = n
+			}
+		case "-d", "--diff":
+			opts.diff = true
+		case "-v":
+			opts.verbose = true
+		default:
+			opts.files = append(opts.files, arg)
+		}
+	}
+	switch {
+	case opts.diff && len(opts.files) != 2:
+		return opts, fmt.Errorf("-d needs exactly two files")
+	case !opts.diff && len(opts.files) != 1:
+		return opts, fmt.Errorf("expected one filename")
+	}
+	return opts, nil
+}
+
+// hexRow formats one dump row: offset, hex bytes in two groups of eight and
+// the printable ASCII column. Bytes whose index is set in marked are
+// highlighted.
+func hexRow(offset int64, row []byte, marked []bool, color bool) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%08x  ", offset)
+	for i := 0; i < hexRowWidth; i++ {
+		if i == hexRowWidth/2 {
+			b.WriteByte(' ')
+		}
+		if i >= len(row) {
+			b.WriteString("   ")
+			continue
+		}
+		hex := fmt.Sprintf("%02x", row[i])
+		if marked != nil && marked[i] {
+			if color {
+				hex = paint(tokMatch, hex)
+			} else {
+				b.WriteString(hex + "*")
+				continue
+			}
+		}
+		b.WriteString(hex + " ")
+	}
+	b.WriteString(" |")
+	for i, c := range row {
+		ch := "."
+		if c >= 0x20 && c < 0x7f {
+			ch = string(c)
+		}
+		if color && marked != nil && marked[i] {
+			ch = paint(tokMatch, ch)
+		}
+		b.WriteString(ch)
+	}
+	b.WriteString("|")
+	return b.String()
+}
+
+// hexRange resolves the requested offset and length against the file size
+func hexRange(size int64, opts hexOptions) (int64, int64, error) {
+	start := opts.offset
+	if start < 0 {
+		start = max(size+start, 0)
+	}
+	if start > size {
+		return 0, 0, fmt.Errorf("offset %d is past the end of the file (%d bytes)", start, size)
+	}
+	length := size - start
+	if opts.length >= 0 {
+		length = min(length, opts.length)
+	}
+	return start, length, nil
+}
+
+// hexDump writes rows for length bytes of r starting at start. Runs of
+// identical rows are collapsed to "*" unless verbose is set.
+func hexDump(w io.Writer, r io.ReaderAt, start, length int64, verbose bool) error {
+	reader := bufio.NewReaderSize(io.NewSectionReader(r, start, length), 64*1024)
+	row := make([]byte, hexRowWidth)
+	var prev []byte
+	collapsed := false
+	offset := start
+	for {
+		n, err := io.ReadFull(reader, row)
+		if n > 0 {
+			if !verbose && prev != nil && n == hexRowWidth && bytes.Equal(prev, row) {
+				if !collapsed {
+					fmt.Fprintln(w, "*")
+					collapsed = true
+				}
+			} else {
+				fmt.Fprintln(w, hexRow(offset, row[:n], nil, false))
+				prev = append(prev[:0], row[:n]...)
+				collapsed = false
+			}
+			offset += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "%08x\n", offset)
+	return nil
+}
+
+// gxhex prints a hex dump of a file, or a byte-by-byte diff of two files
+func gxhex(opts hexOptions) {
+	if opts.diff {
+		hexDiff(opts)
+		return
+	}
+
+	filename := opts.files[0]
+	file, err := os.Open(filename)
+	if err != nil {
+		fmt.Printf("Error opening file '%s': %v\n", filename, err)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		fmt.Printf("Error: '%s' is not a regular file\n", filename)
+		return
+	}
+
+	start, length, err := hexRange(info.Size(), opts)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Printf("\n--- %s: %d bytes from offset %d (0x%x) ---\n", filename, length, start, start)
+	out := bufio.NewWriter(os.Stdout)
+	if err := hexDump(out, file, start, length, opts.verbose); err != nil {
+		out.Flush()
+		fmt.Printf("Error reading file: %v\n", err)
+		return
+	}
+	out.Flush()
+}
+
+// hexDiff compares two files byte by byte and prints each differing row of
+// both files with the changed bytes highlighted
+func hexDiff(opts hexOptions) {
+	var files [2]*os.File
+	var sizes [2]int64
+	for i, name := range opts.files {
+		file, err := os.Open(name)
+		if err != nil {
+			fmt.Printf("Error opening file '%s': %v\n", name, err)
+			return
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil || info.IsDir() {
+			fmt.Printf("Error: '%s' is not a regular file\n", name)
+			return
+		}
+		files[i], sizes[i] = file, info.Size()
+	}
+
+	start, length, err := hexRange(max(sizes[0], sizes[1]), opts)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	color := colorsEnabled()
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	fmt.Fprintf(out, "\n--- Comparing %s (%d bytes) with %s (%d bytes) ---\n",
+		opts.files[0], sizes[0], opts.files[1], sizes[1])
+
+	readers := [2]*bufio.Reader{}
+	for i, file := range files {
+		readers[i] = bufio.NewReaderSize(io.NewSectionReader(file, start, length), 64*1024)
+	}
+	rows := [2][]byte{make([]byte, hexRowWidth), make([]byte, hexRowWidth)}
+	marked := make([]bool, hexRowWidth)
+	var diffBytes, diffRows int64
+	firstDiff := int64(-1)
+
+	for offset := start; offset < start+length; offset += hexRowWidth {
+		var n [2]int
+		for i := range readers {
+			var err error
+			n[i], err = io.ReadFull(readers[i], rows[i])
+			if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+				fmt.Fprintf(out, "Error reading file '%s': %v\n", opts.files[i], err)
+				return
+			}
+		}
+		width := max(n[0], n[1])
+		changed := false
+		for j := 0; j < width; j++ {
+			marked[j] = j >= n[0] || j >= n[1] || rows[0][j] != rows[1][j]
+			if marked[j] {
+				changed = true
+				diffBytes++
+				if firstDiff < 0 {
+					firstDiff = offset + int64(j)
+				}
+			}
+		}
+		if !changed {
+			continue
+		}
+		diffRows++
+		fmt.Fprintf(out, "%s  %s\n", hexRow(offset, rows[0][:n[0]], marked, color), opts.files[0])
+		fmt.Fprintf(out, "%s  %s\n", hexRow(offset, rows[1][:n[1]], marked, color), opts.files[1])
+	}
+
+	if diffBytes == 0 {
+		fmt.Fprintln(out, "✅ Files are identical in the compared range")
+		return
+	}
+	fmt.Fprintf(out, "--- %d differing byte(s) in %d row(s); first difference at offset %d (0x%x) ---\n",
+		diffBytes, diffRows, firstDiff, firstDiff)
+	if sizes[0] != sizes[1] {
+		shorter := opts.files[0]
+		if sizes[1] < sizes[0] {
+			shorter = opts.files[1]
+		}
+		fmt.Fprintf(out, "⚠️  %s ends at offset %d\n", shorter, min(sizes[0], sizes[1]))
+	}
+}
+
+// showBinaryPreview is what gxcat shows instead of raw binary content
+func showBinaryPreview(filename string, file *os.File, size int64) {
+	fmt.Printf("\n⚠️  '%s' looks like a binary file (%s); showing the first %d bytes as hex.\n",
+		filename, formatBytes(size), min(size, gxcatHexPreview))
+	fmt.Printf("   Use 'gxhex %s' to see the rest.\n", filename)
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	if err := hexDump(out, file, 0, min(size, gxcatHexPreview), false); err != nil {
+		fmt.Fprintf(out, "Error reading file: %v\n", err)
+	}
+}
@@ -0,0 +1,433 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ==================== SYNTAX HIGHLIGHTING ====================
+
+// span marks bytes [start, end) of a line as one token kind
+type span struct {
+	start, end int
+	kind       tokenKind
+}
+
+// lexState carries constructs that continue onto the next line
+type lexState uint8
+
+const (
+	lexNormal       lexState = iota
+	lexBlockComment          // inside /* ... */
+	lexRawString             // inside a multi-line `raw string`
+	lexFence                 // inside a Markdown ``` code block
+)
+
+// lexer describes a language for the generic line tokenizer. Markdown and
+// YAML need a little extra handling, done by their own span functions.
+type lexer struct {
+	name         string
+	lineComment  string
+	blockComment [2]string
+	quotes       string // characters that open a string
+	rawQuote     byte   // opens a string that may span lines
+	variables    bool   // $NAME and ${...} are variables
+	keys         bool   // a string followed by ':' is a key
+	keywords     map[string]bool
+	types        map[string]bool
+	spans        func(l *lexer, line string, st *lexState) []span
+}
+
+// words turns a space-separated list into a set
+func words(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(list) {
+		set[w] = true
+	}
+	return set
+}
+
+var (
+	goLexer = &lexer{
+		name:         "go",
+		lineComment:  "//",
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'",
+		rawQuote:     '`',
+		keywords: words("break case chan const continue default defer else fallthrough for func go goto if " +
+			"import interface map package range return select struct switch type var true false nil iota"),
+		types: words("any bool byte comparable complex64 complex128 error float32 float64 int int8 int16 int32 int64 " +
+			"rune string uint uint8 uint16 uint32 uint64 uintptr append cap clear close complex copy delete imag " +
+			"len make max min new panic print println real recover"),
+		spans: codeSpans,
+	}
+	jsonLexer = &lexer{
+		name:     "json",
+		quotes:   "\"",
+		keys:     true,
+		keywords: words("true false null"),
+		spans:    codeSpans,
+	}
+	yamlLexer = &lexer{
+		name:        "yaml",
+		lineComment: "#",
+		quotes:      "\"'",
+		spans:       yamlSpans,
+	}
+	shellLexer = &lexer{
+		name:        "shell",
+		lineComment: "#",
+		quotes:      "\"'",
+		variables:   true,
+		keywords: words("if then else elif fi for while until do done case esac in function select " +
+			"return break continue local export readonly declare time"),
+		types: words("echo printf cd pwd exit set unset read test source alias unalias shift trap eval exec " +
+			"true false type command builtin getopts wait kill"),
+		spans: codeSpans,
+	}
+	markdownLexer = &lexer{name: "markdown", spans: markdownSpans}
+)
+
+// lexersByExt selects a lexer from a file extension or a well-known name
+var lexersByExt = map[string]*lexer{
+	".go":       goLexer,
+	".json":     jsonLexer,
+	".yaml":     yamlLexer,
+	".yml":      yamlLexer,
+	".md":       markdownLexer,
+	".markdown": markdownLexer,
+	".sh":       shellLexer,
+	".bash":     shellLexer,
+	".zsh":      shellLexer,
+	".bashrc":   shellLexer,
+	".zshrc":    shellLexer,
+	".profile":  shellLexer,
+}
+
+// shebangShells are interpreters whose scripts use the shell lexer
+var shebangShells = words("sh bash zsh dash ksh ash")
+
+// lexerFor picks a lexer for the file by extension, falling back to the
+// interpreter named on a "#!" first line. It returns nil for unknown files.
+func lexerFor(filename string, file *os.File) *lexer {
+	base := filepath.Base(filename)
+	if l := lexersByExt[strings.ToLower(filepath.Ext(base))]; l != nil {
+		return l
+	}
+	if l := lexersByExt[base]; l != nil {
+		return l
+	}
+
+	head := make([]byte, 128)
+	n, _ := file.ReadAt(head, 0)
+	first, _, _ := strings.Cut(string(head[:n]), "\n")
+	if !strings.HasPrefix(first, "#!") {
+		return nil
+	}
+	fields := strings.Fields(first[2:])
+	if len(fields) == 0 {
+		return nil
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" && len(fields) > 1 {
+		interpreter = fields[1]
+	}
+	if shebangShells[interpreter] {
+		return shellLexer
+	}
+	return nil
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+func isDigitByte(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// scanString returns the index just past the string starting at line[i],
+// or -1 when it is not closed on this line
+func scanString(line string, i int, escapes bool) int {
+	quote := line[i]
+	for j := i + 1; j < len(line); j++ {
+		switch line[j] {
+		case '\\':
+			if escapes {
+				j++
+			}
+		case quote:
+			return j + 1
+		}
+	}
+	return -1
+}
+
+// codeSpans is the generic tokenizer for comments, strings, numbers,
+// keywords, builtins and (for shell) variables
+func codeSpans(l *lexer, line string, st *lexState) []span {
+	var out []span
+	i := 0
+
+	// Finish constructs left open by the previous line
+	switch *st {
+	case lexBlockComment:
+		end := strings.Index(line, l.blockComment[1])
+		if end < 0 {
+			return []span{{0, len(line), tokComment}}
+		}
+		i = end + len(l.blockComment[1])
+		out = append(out, span{0, i, tokComment})
+		*st = lexNormal
+	case lexRawString:
+		end := strings.IndexByte(line, l.rawQuote)
+		if end < 0 {
+			return []span{{0, len(line), tokString}}
+		}
+		i = end + 1
+		out = append(out, span{0, i, tokString})
+		*st = lexNormal
+	}
+
+	for i < len(line) {
+		c := line[i]
+		rest := line[i:]
+		switch {
+		case l.lineComment != "" && strings.HasPrefix(rest, l.lineComment) &&
+			(l.lineComment != "#" || i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return append(out, span{i, len(line), tokComment})
+
+		case l.blockComment[0] != "" && strings.HasPrefix(rest, l.blockComment[0]):
+			end := strings.Index(line[i+len(l.blockComment[0]):], l.blockComment[1])
+			if end < 0 {
+				*st = lexBlockComment
+				return append(out, span{i, len(line), tokComment})
+			}
+			next := i + len(l.blockComment[0]) + end + len(l.blockComment[1])
+			out = append(out, span{i, next, tokComment})
+			i = next
+
+		case l.rawQuote != 0 && c == l.rawQuote:
+			end := strings.IndexByte(line[i+1:], l.rawQuote)
+			if end < 0 {
+				*st = lexRawString
+				return append(out, span{i, len(line), tokString})
+			}
+			out = append(out, span{i, i + end + 2, tokString})
+			i += end + 2
+
+		case strings.IndexByte(l.quotes, c) >= 0 &&
+			(l.name != "yaml" || i == 0 || strings.IndexByte(" \t[{,:", line[i-1]) >= 0):
+			// Shell single quotes have no escapes
+			end := scanString(line, i, l.name != "shell" || c != '\'')
+			if end < 0 {
+				end = len(line)
+			}
+			kind := tokString
+			if l.keys && strings.HasPrefix(strings.TrimLeft(line[end:], " \t"), ":") {
+				kind = tokKey
+			}
+			out = append(out, span{i, end, kind})
+			i = end
+
+		case l.variables && c == '$' && i+1 < len(line):
+			end := i + 1
+			if line[end] == '{' {
+				if close := strings.IndexByte(line[end:], '}'); close >= 0 {
+					end += close + 1
+				} else {
+					end = len(line)
+				}
+			} else if isIdentByte(line[end]) {
+				for end < len(line) && isIdentByte(line[end]) {
+					end++
+				}
+			} else if strings.IndexByte("?!#$@*-0123456789", line[end]) >= 0 {
+				end++
+			}
+			if end > i+1 {
+				out = append(out, span{i, end, tokVariable})
+			}
+			i = max(end, i+1)
+
+		case isDigitByte(c) || (c == '-' && l.name == "json" && i+1 < len(line) && isDigitByte(line[i+1])):
+			end := i + 1
+			for end < len(line) && (isIdentByte(line[end]) || line[end] == '.' ||
+				((line[end] == '-' || line[end] == '+') && (line[end-1] == 'e' || line[end-1] == 'E'))) {
+				end++
+			}
+			if i == 0 || !isIdentByte(line[i-1]) {
+				out = append(out, span{i, end, tokNumber})
+			}
+			i = end
+
+		case isIdentByte(c):
+			end := i + 1
+			for end < len(line) && (isIdentByte(line[end]) || (l.name == "shell" && line[end] == '-')) {
+				end++
+			}
+			word := line[i:end]
+			if l.keywords[word] {
+				out = append(out, span{i, end, tokKeyword})
+			} else if l.types[word] {
+				out = append(out, span{i, end, tokType})
+			}
+			i = end
+
+		default:
+			i++
+		}
+	}
+	return out
+}
+
+// yamlKey matches "key:" (optionally after a list dash) at the start of a line
+var yamlKey = regexp.MustCompile(`^(\s*(?:-\s+)?)([^\s#'"{}\[\],&*!|>%@` + "`" + `][^:#]*?|"[^"]*"|'[^']*')\s*:(?:\s|$)`)
+
+// yamlSpans highlights keys and document markers, then the value as code
+func yamlSpans(l *lexer, line string, st *lexState) []span {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "---" || trimmed == "..." {
+		return []span{{0, len(line), tokKeyword}}
+	}
+	var out []span
+	rest := 0
+	if m := yamlKey.FindStringSubmatchIndex(line); m != nil {
+		out = append(out, span{m[4], m[5], tokKey})
+		rest = m[5]
+	}
+	for _, s := range codeSpans(l, line[rest:], st) {
+		out = append(out, span{s.start + rest, s.end + rest, s.kind})
+	}
+	// Booleans and null only count as the whole value
+	value := strings.TrimSpace(line[rest:])
+	if cut := strings.Index(value, " #"); cut >= 0 {
+		value = strings.TrimSpace(value[:cut])
+	}
+	value = strings.TrimSpace(strings.TrimPrefix(value, "- "))
+	if yamlScalars[value] {
+		start := rest + strings.Index(line[rest:], value)
+		out = insertSpan(out, span{start, start + len(value), tokKeyword})
+	}
+	// Anchors and aliases
+	for _, m := range yamlAnchor.FindAllStringSubmatchIndex(line[rest:], -1) {
+		if !insideSpans(out, m[2]+rest) {
+			out = insertSpan(out, span{m[2] + rest, m[3] + rest, tokVariable})
+		}
+	}
+	return out
+}
+
+var yamlScalars = words("true false null yes no on off True False Null TRUE FALSE NULL ~")
+
+var yamlAnchor = regexp.MustCompile(`(?:^|\s)([&*][\w-]+)`)
+
+// insideSpans reports whether byte offset pos falls in one of the spans
+func insideSpans(spans []span, pos int) bool {
+	for _, s := range spans {
+		if pos >= s.start && pos < s.end {
+			return true
+		}
+	}
+	return false
+}
+
+// insertSpan adds s to spans keeping them ordered by start
+func insertSpan(spans []span, s span) []span {
+	i := 0
+	for i < len(spans) && spans[i].start < s.start {
+		i++
+	}
+	spans = append(spans, span{})
+	copy(spans[i+1:], spans[i:])
+	spans[i] = s
+	return spans
+}
+
+var (
+	mdListMarker = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s`)
+	mdInline     = regexp.MustCompile("`[^`]+`|\\[[^\\]]*\\]\\([^)]*\\)")
+)
+
+// markdownSpans highlights headings, quotes, list markers, fenced code
+// blocks, inline code and links
+func markdownSpans(l *lexer, line string, st *lexState) []span {
+	trimmed := strings.TrimSpace(line)
+	isFence := strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+	if *st == lexFence {
+		if isFence {
+			*st = lexNormal
+		}
+		return []span{{0, len(line), tokCode}}
+	}
+	if isFence {
+		*st = lexFence
+		return []span{{0, len(line), tokCode}}
+	}
+
+	switch {
+	case strings.HasPrefix(trimmed, "#"):
+		level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+		if level <= 6 && (len(trimmed) == level || trimmed[level] == ' ') {
+			return []span{{0, len(line), tokHeading}}
+		}
+	case strings.HasPrefix(trimmed, ">"):
+		return []span{{0, len(line), tokComment}}
+	}
+
+	var out []span
+	start := 0
+	if m := mdListMarker.FindStringIndex(line); m != nil {
+		out = append(out, span{m[0], m[1], tokKeyword})
+		start = m[1]
+	}
+	for _, m := range mdInline.FindAllStringIndex(line[start:], -1) {
+		s, e := m[0]+start, m[1]+start
+		if line[s] == '`' {
+			out = append(out, span{s, e, tokCode})
+			continue
+		}
+		// [text](url): text as a key, url as a string
+		mid := strings.Index(line[s:e], "](") + s
+		out = append(out, span{s, mid + 1, tokKey}, span{mid + 1, e, tokString})
+	}
+	return out
+}
+
+// colorize renders line with each span wrapped in its theme color
+func colorize(line string, spans []span) string {
+	if len(spans) == 0 {
+		return line
+	}
+	var b strings.Builder
+	pos := 0
+	for _, s := range spans {
+		if s.start < pos || s.end > len(line) {
+			continue
+		}
+		b.WriteString(line[pos:s.start])
+		b.WriteString(paint(s.kind, line[s.start:s.end]))
+		pos = s.end
+	}
+	b.WriteString(line[pos:])
+	return b.String()
+}
+
+// highlightCopy writes r to w with syntax highlighting and returns the
+// number of bytes read and whether the input ended with a newline
+func highlightCopy(w io.Writer, r io.Reader, l *lexer) (int64, bool, error) {
+	var n int64
+	endsWithNewline := false
+	st := lexNormal
+	err := forEachLine(r, func(line, eol string) error {
+		n += int64(len(line) + len(eol))
+		endsWithNewline = eol != ""
+		text := strings.TrimSuffix(line, "\r")
+		_, err := io.WriteString(w, colorize(text, l.spans(l, text, &st))+line[len(text):]+eol)
+		return err
+	})
+	return n, endsWithNewline, err
+}
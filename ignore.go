@@ -0,0 +1,233 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ==================== IGNORE FILES ====================
+
+// ignoreFiles are read in every directory during a walk; later files take
+// precedence, so .ignore can re-include something .gitignore excludes
+var ignoreFiles = []string{".gitignore", ".ignore"}
+
+// ignoreRule is one pattern line from a .gitignore or .ignore file
+type ignoreRule struct {
+	base     string // absolute directory containing the ignore file
+	re       *regexp.Regexp
+	negate   bool // "!pattern" re-includes a path
+	dirOnly  bool // "pattern/" only matches directories
+	anchored bool // patterns with a slash match relative to base
+}
+
+// match reports whether the rule applies to an absolute path
+func (r ignoreRule) match(absPath string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	target := filepath.Base(absPath)
+	if r.anchored {
+		rel, err := filepath.Rel(r.base, absPath)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return false
+		}
+		target = filepath.ToSlash(rel)
+	}
+	return r.re.MatchString(target)
+}
+
+// isIgnored applies the rules in order; the last matching rule wins
+func isIgnored(rules []ignoreRule, absPath string, isDir bool) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.match(absPath, isDir) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// compileIgnorePattern converts a gitignore glob into an anchored regexp
+func compileIgnorePattern(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case pattern[i:] == "/**":
+			b.WriteString("/.*")
+			i += 2
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil
+	}
+	return re
+}
+
+// parseIgnoreLine turns one ignore-file line into a rule (ok is false for
+// blank lines, comments and invalid patterns)
+func parseIgnoreLine(line, base string) (ignoreRule, bool) {
+	rule := ignoreRule{base: base}
+	line = strings.TrimRight(line, "\r")
+	if !strings.HasSuffix(line, `\ `) {
+		line = strings.TrimRight(line, " ")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule, false
+	}
+
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return rule, false
+	}
+
+	rule.re = compileIgnorePattern(line)
+	return rule, rule.re != nil
+}
+
+// loadIgnoreRules reads the ignore files in an absolute directory
+func loadIgnoreRules(absDir string) []ignoreRule {
+	var rules []ignoreRule
+	for _, name := range ignoreFiles {
+		data, err := os.ReadFile(filepath.Join(absDir, name))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if rule, ok := parseIgnoreLine(line, absDir); ok {
+				rules = append(rules, rule)
+			}
+		}
+	}
+	return rules
+}
+
+// ancestorIgnoreRules loads the ignore files of the directories above absRoot
+// up to the enclosing repository root (the nearest directory with a .git
+// entry). Outside a repository no parent rules apply.
+func ancestorIgnoreRules(absRoot string) []ignoreRule {
+	if _, err := os.Lstat(filepath.Join(absRoot, ".git")); err == nil {
+		return nil
+	}
+
+	var dirs []string
+	for dir := absRoot; ; {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dirs = append([]string{parent}, dirs...)
+		if _, err := os.Lstat(filepath.Join(parent, ".git")); err == nil {
+			break
+		}
+		dir = parent
+	}
+
+	var rules []ignoreRule
+	for _, dir := range dirs {
+		rules = append(rules, loadIgnoreRules(dir)...)
+	}
+	return rules
+}
+
+// walkFilter decides which entries a recursive walk visits
+type walkFilter struct {
+	hidden   bool // include dot files and directories
+	noIgnore bool // do not read .gitignore/.ignore files
+	dirs     bool // also visit directories (before their contents)
+}
+
+// walkFiltered walks root in lexical order and calls visit for every regular
+// file (and directory, with filter.dirs) that survives the hidden-file and
+// ignore rules. .git directories are always skipped and symlinks are not
+// followed. Directory read errors are passed to fail and the walk continues.
+func walkFiltered(root string, filter walkFilter, visit func(path string), fail func(error)) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		fail(err)
+		return
+	}
+	var rules []ignoreRule
+	if !filter.noIgnore {
+		rules = ancestorIgnoreRules(absRoot)
+	}
+	walkFilteredDir(root, absRoot, rules, filter, visit, fail)
+}
+
+// walkFilteredDir visits one directory of a filtered walk
+func walkFilteredDir(dir, absDir string, rules []ignoreRule, filter walkFilter, visit func(path string), fail func(error)) {
+	if !filter.noIgnore {
+		// Copy before appending so sibling directories do not share rules
+		rules = append(rules[:len(rules):len(rules)], loadIgnoreRules(absDir)...)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if name == ".git" || (!filter.hidden && strings.HasPrefix(name, ".")) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		absPath := filepath.Join(absDir, name)
+		isDir := entry.IsDir()
+		if isIgnored(rules, absPath, isDir) {
+			continue
+		}
+		if isDir {
+			if filter.dirs {
+				visit(path)
+			}
+			walkFilteredDir(path, absPath, rules, filter, visit, fail)
+		} else if entry.Type().IsRegular() {
+			visit(path)
+		}
+	}
+}
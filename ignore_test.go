@@ -0,0 +1,47 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIsIgnored(t *testing.T) {
+	base := filepath.FromSlash("/repo")
+	tests := []struct {
+		name  string
+		lines []string
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"basename anywhere", []string{"*.log"}, "src/debug.log", false, true},
+		{"no match", []string{"*.log"}, "src/main.go", false, false},
+		{"negation re-includes", []string{"*.log", "!keep.log"}, "logs/keep.log", false, false},
+		{"last rule wins", []string{"!keep.log", "*.log"}, "keep.log", false, true},
+		{"dir only skips files", []string{"build/"}, "build", false, false},
+		{"dir only matches dirs", []string{"build/"}, "sub/build", true, true},
+		{"leading slash anchors", []string{"/todo.txt"}, "todo.txt", false, true},
+		{"anchored not nested", []string{"/todo.txt"}, "docs/todo.txt", false, false},
+		{"inner slash anchors", []string{"docs/*.md"}, "docs/a.md", false, true},
+		{"inner slash not nested", []string{"docs/*.md"}, "x/docs/a.md", false, false},
+		{"star stays in one dir", []string{"docs/*.md"}, "docs/sub/a.md", false, false},
+		{"double star prefix", []string{"**/gen/*.go"}, "a/b/gen/x.go", false, true},
+		{"double star suffix", []string{"vendor/**"}, "vendor/x/y.go", false, true},
+		{"character class", []string{"file[0-9].txt"}, "file7.txt", false, true},
+		{"negated class", []string{"file[!0-9].txt"}, "file7.txt", false, false},
+		{"escaped bang", []string{`\!important`}, "!important", false, true},
+		{"comment", []string{"#*.go"}, "main.go", false, false},
+	}
+	for _, tt := range tests {
+		var rules []ignoreRule
+		for _, line := range tt.lines {
+			if rule, ok := parseIgnoreLine(line, base); ok {
+				rules = append(rules, rule)
+			}
+		}
+		path := filepath.Join(base, filepath.FromSlash(tt.path))
+		if got := isIgnored(rules, path, tt.isDir); got != tt.want {
+			t.Errorf("%s: isIgnored(%q, %s) = %v, want %v", tt.name, tt.lines, tt.path, got, tt.want)
+		}
+	}
+}
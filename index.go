@@ -0,0 +1,529 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ==================== FILE INDEX ====================
+
+// An index records every entry under a root directory (path, type, size and
+// mtime) so gxfind and gxfzf can answer without walking the tree. Indexes
+// live in the config directory under index/, one file per root, named after
+// a hash of the root's absolute path.
+//
+// The file is gzip-compressed. After a header (magic, root, build time and
+// entry count) each entry is stored as the length of the prefix it shares
+// with the previous path, the remaining path bytes, a flags byte, the size
+// and the mtime, all as varints. Entries are in walk order, so neighbouring
+// paths share long prefixes and compress well.
+//
+// A refresh re-reads only directories whose mtime changed since the last
+// build; unchanged directories reuse their recorded children, which are
+// still re-stat'ed so sizes and mtimes stay current.
+
+const indexMagic = "GXIDX\x01"
+
+// Entry flags
+const (
+	indexDir     byte = 1 << iota
+	indexSymlink      // symbolic link (not followed)
+	indexOther        // device, socket or pipe
+	indexIgnored      // excluded by .gitignore/.ignore (or inside an excluded directory)
+)
+
+// indexEntry is one file or directory in an index
+type indexEntry struct {
+	path  string // slash-separated, relative to the root; "." for the root
+	flags byte
+	size  int64
+	mtime int64 // unix nanoseconds
+}
+
+// fileIndex is a loaded index
+type fileIndex struct {
+	root    string // absolute path
+	built   time.Time
+	entries []indexEntry
+}
+
+// indexFileInfo presents an index entry as an os.FileInfo
+type indexFileInfo struct{ e *indexEntry }
+
+func (i indexFileInfo) Name() string       { return filepath.Base(filepath.FromSlash(i.e.path)) }
+func (i indexFileInfo) Size() int64        { return i.e.size }
+func (i indexFileInfo) ModTime() time.Time { return time.Unix(0, i.e.mtime) }
+func (i indexFileInfo) IsDir() bool        { return i.e.flags&indexDir != 0 }
+func (i indexFileInfo) Sys() any           { return nil }
+func (i indexFileInfo) Mode() os.FileMode {
+	switch {
+	case i.e.flags&indexDir != 0:
+		return os.ModeDir | 0755
+	case i.e.flags&indexSymlink != 0:
+		return os.ModeSymlink | 0777
+	case i.e.flags&indexOther != 0:
+		return os.ModeIrregular | 0644
+	}
+	return 0644
+}
+
+// indexFileFor returns where the index of an absolute root is stored
+func indexFileFor(absRoot string) (string, error) {
+	sum := sha256.Sum256([]byte(absRoot))
+	return configPath(filepath.Join("index", hex.EncodeToString(sum[:8])+".idx"))
+}
+
+// writeIndex atomically stores an index
+func writeIndex(idx *fileIndex) (int64, error) {
+	path, err := indexFileFor(idx.root)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return 0, err
+	}
+
+	err = atomicWrite(path, 0600, func(w io.Writer) error {
+		zw := gzip.NewWriter(w)
+		bw := bufio.NewWriter(zw)
+		buf := make([]byte, binary.MaxVarintLen64)
+		putUvarint := func(v uint64) { bw.Write(buf[:binary.PutUvarint(buf, v)]) }
+		putVarint := func(v int64) { bw.Write(buf[:binary.PutVarint(buf, v)]) }
+		putString := func(s string) {
+			putUvarint(uint64(len(s)))
+			bw.WriteString(s)
+		}
+
+		bw.WriteString(indexMagic)
+		putString(idx.root)
+		putVarint(idx.built.UnixNano())
+		putUvarint(uint64(len(idx.entries)))
+
+		prev := ""
+		for _, e := range idx.entries {
+			shared := 0
+			for shared < len(prev) && shared < len(e.path) && prev[shared] == e.path[shared] {
+				shared++
+			}
+			putUvarint(uint64(shared))
+			putString(e.path[shared:])
+			bw.WriteByte(e.flags)
+			putUvarint(uint64(e.size))
+			putVarint(e.mtime)
+			prev = e.path
+		}
+
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		return zw.Close()
+	})
+	if err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// readIndex loads an index file
+func readIndex(path string) (*fileIndex, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt index '%s': %w", path, err)
+	}
+	br := bufio.NewReader(zr)
+
+	readString := func() (string, error) {
+		n, err := binary.ReadUvarint(br)
+		if err != nil {
+			return "", err
+		}
+		if n > MAX_PATH_LENGTH*16 {
+			return "", errors.New("path too long")
+		}
+		b := make([]byte, n)
+		_, err = io.ReadFull(br, b)
+		return string(b), err
+	}
+
+	fail := func(err error) (*fileIndex, error) {
+		return nil, fmt.Errorf("corrupt index '%s': %w", path, err)
+	}
+
+	magic := make([]byte, len(indexMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != indexMagic {
+		return fail(errors.New("bad header"))
+	}
+	idx := &fileIndex{}
+	if idx.root, err = readString(); err != nil {
+		return fail(err)
+	}
+	built, err := binary.ReadVarint(br)
+	if err != nil {
+		return fail(err)
+	}
+	idx.built = time.Unix(0, built)
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return fail(err)
+	}
+
+	idx.entries = make([]indexEntry, 0, min(count, 1<<20))
+	prev := ""
+	for i := uint64(0); i < count; i++ {
+		shared, err := binary.ReadUvarint(br)
+		if err != nil || shared > uint64(len(prev)) {
+			return fail(errors.New("bad entry"))
+		}
+		suffix, err := readString()
+		if err != nil {
+			return fail(err)
+		}
+		var e indexEntry
+		e.path = prev[:shared] + suffix
+		if e.flags, err = br.ReadByte(); err != nil {
+			return fail(err)
+		}
+		size, err := binary.ReadUvarint(br)
+		if err != nil {
+			return fail(err)
+		}
+		e.size = int64(size)
+		if e.mtime, err = binary.ReadVarint(br); err != nil {
+			return fail(err)
+		}
+		idx.entries = append(idx.entries, e)
+		prev = e.path
+	}
+	return idx, nil
+}
+
+// findIndex returns the index covering path (the index of path itself or of
+// its nearest indexed ancestor), or nil when there is none
+func findIndex(path string) *fileIndex {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil
+	}
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		if file, err := indexFileFor(dir); err == nil {
+			if _, err := os.Stat(file); err == nil {
+				if idx, err := readIndex(file); err == nil && idx.root == dir {
+					return idx
+				}
+			}
+		}
+		if filepath.Dir(dir) == dir {
+			return nil
+		}
+	}
+}
+
+// indexScan walks the tree for a build or refresh
+type indexScan struct {
+	root    string
+	old     map[string]*indexEntry
+	kids    map[string][]string // children recorded in the old index
+	entries []indexEntry
+	rescans int
+	prog    *progress
+}
+
+// entryFor builds the index entry for a path from its Lstat info
+func entryFor(rel string, info os.FileInfo, parentFlags byte) indexEntry {
+	e := indexEntry{path: rel, size: info.Size(), mtime: info.ModTime().UnixNano()}
+	e.flags = parentFlags & indexIgnored
+	switch {
+	case info.IsDir():
+		e.flags |= indexDir
+		e.size = 0
+	case info.Mode()&os.ModeSymlink != 0:
+		e.flags |= indexSymlink
+	case !info.Mode().IsRegular():
+		e.flags |= indexOther
+	}
+	return e
+}
+
+// scanDir records the children of a directory, reusing the old listing when
+// the directory's mtime is unchanged
+func (s *indexScan) scanDir(rel string, dir indexEntry, rules []ignoreRule) {
+	absDir := filepath.Join(s.root, filepath.FromSlash(rel))
+	rules = append(rules[:len(rules):len(rules)], loadIgnoreRules(absDir)...)
+
+	var names []string
+	if old, ok := s.old[rel]; ok && old.flags&indexDir != 0 && old.mtime == dir.mtime {
+		names = s.kids[rel]
+	} else {
+		s.rescans++
+		entries, err := os.ReadDir(absDir)
+		if err != nil {
+			s.prog.printf("⚠️  %v\n", err)
+			return
+		}
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+	}
+
+	for _, name := range names {
+		childRel := name
+		if rel != "." {
+			childRel = rel + "/" + name
+		}
+		absPath := filepath.Join(absDir, name)
+		info, err := os.Lstat(absPath)
+		if err != nil {
+			continue // removed since the last build
+		}
+		e := entryFor(childRel, info, dir.flags)
+		if isIgnored(rules, absPath, info.IsDir()) {
+			e.flags |= indexIgnored
+		}
+		s.entries = append(s.entries, e)
+		s.prog.addFile()
+		if info.IsDir() {
+			s.scanDir(childRel, e, rules)
+		}
+	}
+}
+
+// buildIndex indexes absRoot, reusing old (which may be nil) for unchanged directories
+func buildIndex(absRoot string, old *fileIndex) (*fileIndex, int, error) {
+	info, err := os.Stat(absRoot)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !info.IsDir() {
+		return nil, 0, fmt.Errorf("'%s' is not a directory", absRoot)
+	}
+
+	scan := &indexScan{root: absRoot, old: map[string]*indexEntry{}, kids: map[string][]string{}}
+	if old != nil {
+		for i := range old.entries {
+			e := &old.entries[i]
+			scan.old[e.path] = e
+			if e.path != "." {
+				parent := pathDir(e.path)
+				scan.kids[parent] = append(scan.kids[parent], e.path[strings.LastIndex(e.path, "/")+1:])
+			}
+		}
+	}
+
+	scan.prog = newProgress("Indexing", 0, 0)
+	rootEntry := entryFor(".", info, 0)
+	scan.entries = append(scan.entries, rootEntry)
+	scan.scanDir(".", rootEntry, ancestorIgnoreRules(absRoot))
+	scan.prog.finish()
+
+	return &fileIndex{root: absRoot, built: time.Now(), entries: scan.entries}, scan.rescans, nil
+}
+
+// pathDir returns the parent of a slash-separated index path
+func pathDir(p string) string {
+	if i := strings.LastIndex(p, "/"); i >= 0 {
+		return p[:i]
+	}
+	return "."
+}
+
+// diffIndex counts entries added, removed and changed between two indexes
+func diffIndex(old, cur *fileIndex) (added, removed, changed int) {
+	before := make(map[string]indexEntry, len(old.entries))
+	for _, e := range old.entries {
+		before[e.path] = e
+	}
+	for _, e := range cur.entries {
+		prev, ok := before[e.path]
+		switch {
+		case !ok:
+			added++
+		case prev.flags != e.flags || prev.size != e.size || prev.mtime != e.mtime:
+			changed++
+		}
+		delete(before, e.path)
+	}
+	return added, len(before), changed
+}
+
+// walkIndex calls visit for every indexed entry under root (a path below the
+// index root, as typed by the user) in walk order, with paths relative to
+// the current directory like a live walk would produce
+func walkIndex(idx *fileIndex, root string, visit func(path string, info os.FileInfo, depth int)) bool {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(idx.root, absRoot)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	prefix := filepath.ToSlash(rel)
+
+	for i := range idx.entries {
+		e := &idx.entries[i]
+		var sub string
+		switch {
+		case prefix == ".":
+			sub = e.path
+		case e.path == prefix:
+			sub = "."
+		case strings.HasPrefix(e.path, prefix+"/"):
+			sub = e.path[len(prefix)+1:]
+		default:
+			continue
+		}
+		if sub == "." {
+			visit(root, indexFileInfo{e}, 0)
+			continue
+		}
+		visit(filepath.Join(root, filepath.FromSlash(sub)), indexFileInfo{e}, strings.Count(sub, "/")+1)
+	}
+	return true
+}
+
+// indexNotice tells the user that results come from a possibly stale index
+func indexNotice(idx *fileIndex) {
+	fmt.Printf("🗂️  Using index of %s (built %s ago; gxindex refresh to update)\n",
+		idx.root, formatDuration(time.Since(idx.built).Round(time.Second)))
+}
+
+// gxindex builds, refreshes, shows or removes file indexes
+func gxindex(args []string) {
+	if len(args) == 0 {
+		args = []string{"status"}
+	}
+	dir := "."
+	if len(args) > 1 {
+		dir = args[1]
+	}
+	if !validatePath(dir) {
+		return
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	switch args[0] {
+	case "build", "refresh":
+		var old *fileIndex
+		if args[0] == "refresh" {
+			if old = findIndex(dir); old == nil {
+				fmt.Printf("No index covers '%s' — run: gxindex build\n", dir)
+				return
+			}
+			absDir = old.root
+		}
+
+		start := time.Now()
+		idx, rescans, err := buildIndex(absDir, old)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		size, err := writeIndex(idx)
+		if err != nil {
+			fmt.Printf("Error writing index: %v\n", err)
+			return
+		}
+
+		if old == nil {
+			fmt.Printf("✅ Indexed %d entries under %s in %s (%s on disk)\n",
+				len(idx.entries), idx.root, formatDuration(time.Since(start)), formatBytes(size))
+			return
+		}
+		added, removed, changed := diffIndex(old, idx)
+		fmt.Printf("✅ Refreshed index of %s in %s: %d added, %d removed, %d changed (%d of %d directories re-read)\n",
+			idx.root, formatDuration(time.Since(start)), added, removed, changed, rescans, countDirs(idx))
+
+	case "status":
+		idx := findIndex(dir)
+		if idx == nil {
+			fmt.Printf("No index covers '%s' — run: gxindex build\n", dir)
+			return
+		}
+		file, _ := indexFileFor(idx.root)
+		var size int64
+		if info, err := os.Stat(file); err == nil {
+			size = info.Size()
+		}
+		fmt.Printf("\n--- Index of %s ---\n", idx.root)
+		fmt.Printf("Entries:   %d (%d directories)\n", len(idx.entries), countDirs(idx))
+		fmt.Printf("Built:     %s (%s ago)\n", idx.built.Format("2006-01-02 15:04:05"), formatDuration(time.Since(idx.built).Round(time.Second)))
+		fmt.Printf("On disk:   %s (%s)\n", formatBytes(size), file)
+
+	case "remove":
+		idx := findIndex(dir)
+		if idx == nil {
+			fmt.Printf("No index covers '%s'\n", dir)
+			return
+		}
+		file, err := indexFileFor(idx.root)
+		if err == nil {
+			err = os.Remove(file)
+		}
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Printf("🗑️ Removed index of %s\n", idx.root)
+
+	default:
+		fmt.Printf("Unknown gxindex action: %s\n", args[0])
+		fmt.Println("Usage: gxindex build|refresh|status|remove [dir]")
+	}
+}
+
+// countDirs counts the directories in an index
+func countDirs(idx *fileIndex) int {
+	n := 0
+	for _, e := range idx.entries {
+		if e.flags&indexDir != 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// indexCandidates lists indexed paths under the current directory for gxfzf,
+// applying the same hidden, ignore and directory filters as a live walk
+func indexCandidates(idx *fileIndex, filter walkFilter) []string {
+	var paths []string
+	walkIndex(idx, ".", func(path string, info os.FileInfo, depth int) {
+		e := info.(indexFileInfo).e
+		if depth == 0 || (!info.IsDir() && !info.Mode().IsRegular()) {
+			return
+		}
+		if (info.IsDir() && !filter.dirs) || (!filter.noIgnore && e.flags&indexIgnored != 0) {
+			return
+		}
+		for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+			if part == ".git" || (!filter.hidden && strings.HasPrefix(part, ".")) {
+				return
+			}
+		}
+		paths = append(paths, path)
+	})
+	return paths
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIndexRoundTrip(t *testing.T) {
+	t.Setenv("GX_CONFIG_DIR", t.TempDir())
+	idx := &fileIndex{
+		root:  "/home/user/project",
+		built: time.Unix(1700000000, 123456789),
+		entries: []indexEntry{
+			{".", indexDir, 0, 1700000000000000000},
+			{"src", indexDir, 4096, 1699999999000000001},
+			{"src/main.go", 0, 1234, 1699999998000000000},
+			{"src/main_test.go", 0, 1 << 40, 1},
+			{"src/zz", indexSymlink | indexIgnored, 0, -86400000000000}, // before 1970
+			{"vendor/üñí/ç.txt", indexOther, 127, 0},
+			{"x", 0, 128, 1 << 62},
+		},
+	}
+	if _, err := writeIndex(idx); err != nil {
+		t.Fatal(err)
+	}
+	path, err := indexFileFor(idx.root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := readIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.root != idx.root || !got.built.Equal(idx.built) {
+		t.Errorf("header = %q %v, want %q %v", got.root, got.built, idx.root, idx.built)
+	}
+	if !reflect.DeepEqual(got.entries, idx.entries) {
+		t.Errorf("entries = %+v\nwant %+v", got.entries, idx.entries)
+	}
+}
+
+func TestReadIndexCorrupt(t *testing.T) {
+	t.Setenv("GX_CONFIG_DIR", t.TempDir())
+	idx := &fileIndex{root: "/r", built: time.Now(), entries: []indexEntry{{".", indexDir, 0, 0}, {"a", 0, 1, 2}}}
+	if _, err := writeIndex(idx); err != nil {
+		t.Fatal(err)
+	}
+	path, _ := indexFileFor(idx.root)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range [][]byte{nil, data[:len(data)/2], []byte("GXIDX\x01 not gzip")} {
+		if err := os.WriteFile(path, bad, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := readIndex(path); err == nil {
+			t.Errorf("readIndex accepted %d corrupt bytes", len(bad))
+		}
+	}
+}
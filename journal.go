@@ -0,0 +1,450 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// ==================== UNDO JOURNAL ====================
+
+// Reversible file operations are recorded in a per-session journal. Content
+// that an operation overwrites is stashed in the config directory so it can be
+// put back. Before undoing or redoing, the affected path is compared with the
+// state recorded at the time of the operation so that later edits are never
+// silently discarded.
+
+// Journal entry kinds
+const (
+	journalCreate = "create" // a new file or directory appeared at Path
+	journalMove   = "move"   // From was moved to Path
+	journalModify = "modify" // the contents of Path were rewritten
+	journalTrash  = "trash"  // Path was moved to the trash as TrashID
+	journalLink   = "link"   // Path was replaced by a hard link to From, which has the same contents
+)
+
+// fileState fingerprints a path so later changes can be detected
+type fileState struct {
+	Exists bool
+	IsDir  bool
+	Size   int64
+	Hash   string
+	Mode   os.FileMode
+}
+
+// journalSnap is the state of a path plus a stashed copy of its contents
+type journalSnap struct {
+	State fileState
+	Stash string
+}
+
+// journalEntry records one reversible operation
+type journalEntry struct {
+	ID      int
+	Time    time.Time
+	Kind    string
+	Command string
+	Path    string
+	From    string
+	TrashID string
+	Before  journalSnap
+	After   journalSnap
+}
+
+// journal holds this session's operations; journalPos is the number of
+// entries currently applied (entries past it can be redone)
+var (
+	journal    []*journalEntry
+	journalPos int
+	journalSeq int
+	stashSeq   int
+)
+
+// journalStashDir returns the directory holding this session's stashed contents
+func journalStashDir() (string, error) {
+	dir, err := configPath(filepath.Join("journal", "session-"+strconv.Itoa(os.Getpid())))
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// clearJournal removes the stashed contents when the session ends
+func clearJournal() {
+	journal = nil
+	journalPos = 0
+	if dir, err := configDirPath(); err == nil {
+		os.RemoveAll(filepath.Join(dir, "journal", "session-"+strconv.Itoa(os.Getpid())))
+	}
+}
+
+// statePath fingerprints the current state of a path
+func statePath(path string) fileState {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fileState{}
+	}
+
+	state := fileState{Exists: true, IsDir: info.IsDir(), Size: info.Size(), Mode: info.Mode()}
+	if info.Mode().IsRegular() {
+		if file, err := os.Open(path); err == nil {
+			hasher := sha256.New()
+			if _, err := io.Copy(hasher, file); err == nil {
+				state.Hash = hex.EncodeToString(hasher.Sum(nil))
+			}
+			file.Close()
+		}
+	}
+	return state
+}
+
+// sameState reports whether a path still matches a recorded state
+func sameState(a, b fileState) bool {
+	if a.Exists != b.Exists || a.IsDir != b.IsDir {
+		return false
+	}
+	if a.IsDir {
+		return true
+	}
+	return a.Size == b.Size && a.Hash == b.Hash
+}
+
+// copyRegularFile atomically replaces dst with the contents of src and the given mode
+func copyRegularFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	err = atomicWrite(dst, mode, func(w io.Writer) error {
+		_, err := io.Copy(w, in)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return os.Chmod(dst, mode.Perm())
+}
+
+// journalCapture records the state of a path and stashes a copy of a regular file.
+// Files above MAX_FILE_SIZE are fingerprinted but not stashed.
+func journalCapture(path string) journalSnap {
+	snap := journalSnap{State: statePath(path)}
+	if isDryRun() || !snap.State.Exists || !snap.State.Mode.IsRegular() || snap.State.Size > MAX_FILE_SIZE {
+		return snap
+	}
+
+	dir, err := journalStashDir()
+	if err != nil {
+		return snap
+	}
+	stashSeq++
+	stash := filepath.Join(dir, strconv.Itoa(stashSeq))
+	if err := copyRegularFile(path, stash, 0600); err != nil {
+		os.Remove(stash)
+		return snap
+	}
+	snap.Stash = stash
+	return snap
+}
+
+// recordJournal appends an operation to the journal, discarding any redo history
+func recordJournal(kind, command, path, from string, before journalSnap) {
+	if isDryRun() {
+		return
+	}
+
+	absPath, _ := filepath.Abs(path)
+	if from != "" {
+		from, _ = filepath.Abs(from)
+	}
+
+	entry := &journalEntry{
+		Time:    time.Now(),
+		Kind:    kind,
+		Command: command,
+		Path:    absPath,
+		From:    from,
+		Before:  before,
+	}
+	if kind == journalModify {
+		entry.After = journalCapture(absPath)
+	} else {
+		entry.After = journalSnap{State: statePath(absPath)}
+	}
+	appendJournal(entry)
+}
+
+// recordTrashJournal records a deletion to the trash so it can be restored
+func recordTrashJournal(command, path, trashID string, before journalSnap) {
+	if isDryRun() {
+		return
+	}
+	absPath, _ := filepath.Abs(path)
+	appendJournal(&journalEntry{
+		Time:    time.Now(),
+		Kind:    journalTrash,
+		Command: command,
+		Path:    absPath,
+		TrashID: trashID,
+		Before:  before,
+	})
+}
+
+// appendJournal adds an entry after the current position
+func appendJournal(entry *journalEntry) {
+	for _, old := range journal[journalPos:] {
+		discardStashes(old)
+	}
+	journalSeq++
+	entry.ID = journalSeq
+	journal = append(journal[:journalPos], entry)
+	journalPos = len(journal)
+}
+
+// discardStashes removes the stashed contents held by an entry
+func discardStashes(entry *journalEntry) {
+	for _, stash := range []string{entry.Before.Stash, entry.After.Stash} {
+		if stash != "" {
+			os.Remove(stash)
+		}
+	}
+}
+
+// restoreSnap puts stashed contents back at path
+func restoreSnap(snap journalSnap, path string) error {
+	if snap.State.IsDir {
+		return os.Mkdir(path, snap.State.Mode.Perm())
+	}
+	if snap.Stash == "" {
+		if snap.State.Size == 0 {
+			return atomicWriteFile(path, nil, snap.State.Mode)
+		}
+		return fmt.Errorf("contents of '%s' were not saved (file too large)", path)
+	}
+	return copyRegularFile(snap.Stash, path, snap.State.Mode)
+}
+
+// checkState returns an error describing how path differs from the expected state
+func checkState(path string, expected fileState, force bool) error {
+	if force || sameState(statePath(path), expected) {
+		return nil
+	}
+	if !expected.Exists {
+		return fmt.Errorf("'%s' exists now but did not at the time of the operation", path)
+	}
+	return fmt.Errorf("'%s' has changed since the operation", path)
+}
+
+// describe returns a one-line summary of an entry
+func (e *journalEntry) describe() string {
+	switch e.Kind {
+	case journalCreate:
+		return fmt.Sprintf("create %s", e.Path)
+	case journalMove:
+		return fmt.Sprintf("move %s -> %s", e.From, e.Path)
+	case journalModify:
+		return fmt.Sprintf("modify %s", e.Path)
+	case journalTrash:
+		return fmt.Sprintf("trash %s (id %s)", e.Path, e.TrashID)
+	case journalLink:
+		return fmt.Sprintf("link %s -> %s", e.Path, e.From)
+	}
+	return e.Kind
+}
+
+// undoEntry reverses an operation
+func undoEntry(e *journalEntry, force bool) error {
+	switch e.Kind {
+	case journalCreate:
+		if err := checkState(e.Path, e.After.State, force); err != nil {
+			return err
+		}
+		if e.After.State.IsDir {
+			// Directories may have gained contents, so they go to the trash
+			id, err := moveToTrash(e.Path)
+			if err != nil {
+				return err
+			}
+			e.TrashID = id
+			return nil
+		}
+		if e.After.Stash == "" {
+			e.After = journalCapture(e.Path)
+		}
+		return os.Remove(e.Path)
+
+	case journalMove:
+		if err := checkState(e.Path, e.After.State, force); err != nil {
+			return err
+		}
+		if _, err := os.Lstat(e.From); err == nil {
+			return fmt.Errorf("'%s' exists again; cannot move back", e.From)
+		}
+		if err := movePath(e.Path, e.From); err != nil {
+			return err
+		}
+		if e.Before.State.Exists {
+			return restoreSnap(e.Before, e.Path)
+		}
+		return nil
+
+	case journalModify:
+		if err := checkState(e.Path, e.After.State, force); err != nil {
+			return err
+		}
+		return restoreSnap(e.Before, e.Path)
+
+	case journalTrash:
+		_, err := restoreFromTrash(e.TrashID)
+		return err
+
+	case journalLink:
+		// From still has the old contents, so nothing was stashed: copying it
+		// gives Path back a file of its own
+		if err := checkState(e.Path, e.After.State, force); err != nil {
+			return err
+		}
+		if err := checkState(e.From, e.Before.State, force); err != nil {
+			return err
+		}
+		return copyRegularFile(e.From, e.Path, e.Before.State.Mode)
+	}
+	return fmt.Errorf("unknown journal entry kind '%s'", e.Kind)
+}
+
+// redoEntry re-applies an operation that was undone
+func redoEntry(e *journalEntry, force bool) error {
+	switch e.Kind {
+	case journalCreate:
+		if _, err := os.Lstat(e.Path); err == nil {
+			return fmt.Errorf("'%s' already exists", e.Path)
+		}
+		if e.TrashID != "" {
+			_, err := restoreFromTrash(e.TrashID)
+			return err
+		}
+		return restoreSnap(e.After, e.Path)
+
+	case journalMove:
+		if err := checkState(e.From, e.After.State, force); err != nil {
+			return err
+		}
+		if err := checkState(e.Path, e.Before.State, force); err != nil {
+			return err
+		}
+		return movePath(e.From, e.Path)
+
+	case journalModify:
+		if err := checkState(e.Path, e.Before.State, force); err != nil {
+			return err
+		}
+		return restoreSnap(e.After, e.Path)
+
+	case journalTrash:
+		if err := checkState(e.Path, e.Before.State, force); err != nil {
+			return err
+		}
+		id, err := moveToTrash(e.Path)
+		if err != nil {
+			return err
+		}
+		e.TrashID = id
+		return nil
+
+	case journalLink:
+		if err := checkState(e.Path, e.Before.State, force); err != nil {
+			return err
+		}
+		if err := checkState(e.From, e.Before.State, force); err != nil {
+			return err
+		}
+		return linkDupe(e.From, e.Path)
+	}
+	return fmt.Errorf("unknown journal entry kind '%s'", e.Kind)
+}
+
+// gxundo reverses the most recent journaled operation
+func gxundo(args []string) (int64, error) {
+	force := len(args) > 0 && args[0] == "--force"
+	if journalPos == 0 {
+		fmt.Println("Nothing to undo")
+		return 0, errCancelled
+	}
+
+	entry := journal[journalPos-1]
+	if isDryRun() {
+		dryRunf("would undo #%d: %s", entry.ID, entry.describe())
+		return 0, nil
+	}
+
+	if err := undoEntry(entry, force); err != nil {
+		fmt.Printf("❌ Cannot undo #%d (%s): %v\n", entry.ID, entry.describe(), err)
+		if !force {
+			fmt.Println("Use 'gxundo --force' to undo anyway.")
+		}
+		return 0, err
+	}
+
+	journalPos--
+	fmt.Printf("↩️  Undid #%d: %s\n", entry.ID, entry.describe())
+	return entry.After.State.Size, nil
+}
+
+// gxredo re-applies the most recently undone operation
+func gxredo(args []string) (int64, error) {
+	force := len(args) > 0 && args[0] == "--force"
+	if journalPos >= len(journal) {
+		fmt.Println("Nothing to redo")
+		return 0, errCancelled
+	}
+
+	entry := journal[journalPos]
+	if isDryRun() {
+		dryRunf("would redo #%d: %s", entry.ID, entry.describe())
+		return 0, nil
+	}
+
+	if err := redoEntry(entry, force); err != nil {
+		fmt.Printf("❌ Cannot redo #%d (%s): %v\n", entry.ID, entry.describe(), err)
+		if !force {
+			fmt.Println("Use 'gxredo --force' to redo anyway.")
+		}
+		return 0, err
+	}
+
+	journalPos++
+	fmt.Printf("↪️  Redid #%d: %s\n", entry.ID, entry.describe())
+	return entry.After.State.Size, nil
+}
+
+// gxjournal lists the operations recorded in this session
+func gxjournal() {
+	if len(journal) == 0 {
+		fmt.Println("Journal is empty")
+		return
+	}
+
+	fmt.Println("--- Session journal (newest last) ---")
+	for i, entry := range journal {
+		marker := " "
+		status := ""
+		if i == journalPos-1 {
+			marker = ">"
+		}
+		if i >= journalPos {
+			status = "  (undone)"
+		}
+		fmt.Printf("%s #%-4d %s  %-11s %s%s\n", marker, entry.ID, entry.Time.Format("15:04:05"), entry.Command, entry.describe(), status)
+	}
+	fmt.Printf("--- %d undoable, %d redoable ---\n", journalPos, len(journal)-journalPos)
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupJournal points the config directory and trash at temporary
+// directories and starts from an empty journal
+func setupJournal(t *testing.T) string {
+	t.Helper()
+	t.Setenv("GX_CONFIG_DIR", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	dir := t.TempDir()
+	t.Chdir(dir)
+	journal, journalPos, journalSeq, stashSeq = nil, 0, 0, 0
+	t.Cleanup(clearJournal)
+	return dir
+}
+
+func writeFile(t *testing.T, name, data string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fileContents returns the contents of name, or "<missing>" if it does not exist
+func fileContents(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(name)
+	if os.IsNotExist(err) {
+		return "<missing>"
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func undoOnce(t *testing.T, args ...string) error {
+	t.Helper()
+	var err error
+	captureRun(t, func() { _, err = gxundo(args) })
+	return err
+}
+
+func redoOnce(t *testing.T, args ...string) error {
+	t.Helper()
+	var err error
+	captureRun(t, func() { _, err = gxredo(args) })
+	return err
+}
+
+func TestUndoRedo(t *testing.T) {
+	tests := []struct {
+		name string
+		// run performs and records the operation
+		run func(t *testing.T)
+		// after, undone list the expected contents of each file once the
+		// operation has run and once it has been undone
+		after, undone map[string]string
+	}{
+		{
+			name: "create",
+			run: func(t *testing.T) {
+				writeFile(t, "new.txt", "fresh")
+				recordJournal(journalCreate, "gxtouch", "new.txt", "", journalSnap{})
+			},
+			after:  map[string]string{"new.txt": "fresh"},
+			undone: map[string]string{"new.txt": "<missing>"},
+		},
+		{
+			name: "move",
+			run: func(t *testing.T) {
+				writeFile(t, "a.txt", "moved")
+				before := journalCapture("b.txt")
+				if err := movePath("a.txt", "b.txt"); err != nil {
+					t.Fatal(err)
+				}
+				recordJournal(journalMove, "gxmv", "b.txt", "a.txt", before)
+			},
+			after:  map[string]string{"a.txt": "<missing>", "b.txt": "moved"},
+			undone: map[string]string{"a.txt": "moved", "b.txt": "<missing>"},
+		},
+		{
+			name: "move over an existing file",
+			run: func(t *testing.T) {
+				writeFile(t, "a.txt", "moved")
+				writeFile(t, "b.txt", "overwritten")
+				before := journalCapture("b.txt")
+				if err := movePath("a.txt", "b.txt"); err != nil {
+					t.Fatal(err)
+				}
+				recordJournal(journalMove, "gxmv", "b.txt", "a.txt", before)
+			},
+			after:  map[string]string{"a.txt": "<missing>", "b.txt": "moved"},
+			undone: map[string]string{"a.txt": "moved", "b.txt": "overwritten"},
+		},
+		{
+			name: "modify",
+			run: func(t *testing.T) {
+				writeFile(t, "m.txt", "old contents")
+				before := journalCapture("m.txt")
+				writeFile(t, "m.txt", "new contents")
+				recordJournal(journalModify, "gxreplace", "m.txt", "", before)
+			},
+			after:  map[string]string{"m.txt": "new contents"},
+			undone: map[string]string{"m.txt": "old contents"},
+		},
+		{
+			name: "trash",
+			run: func(t *testing.T) {
+				writeFile(t, "t.txt", "deleted")
+				before := journalCapture("t.txt")
+				id, err := moveToTrash("t.txt")
+				if err != nil {
+					t.Fatal(err)
+				}
+				recordTrashJournal("gxd", "t.txt", id, before)
+			},
+			after:  map[string]string{"t.txt": "<missing>"},
+			undone: map[string]string{"t.txt": "deleted"},
+		},
+		{
+			name: "link",
+			run: func(t *testing.T) {
+				writeFile(t, "keep.txt", "same")
+				writeFile(t, "dup.txt", "same")
+				before := journalSnap{State: statePath("dup.txt")}
+				if err := linkDupe("keep.txt", "dup.txt"); err != nil {
+					t.Fatal(err)
+				}
+				recordJournal(journalLink, "gxdupes", "dup.txt", "keep.txt", before)
+			},
+			after:  map[string]string{"keep.txt": "same", "dup.txt": "same"},
+			undone: map[string]string{"keep.txt": "same", "dup.txt": "same"},
+		},
+	}
+
+	check := func(t *testing.T, stage string, want map[string]string) {
+		t.Helper()
+		for name, contents := range want {
+			if got := fileContents(t, name); got != contents {
+				t.Errorf("%s: %s = %q, want %q", stage, name, got, contents)
+			}
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupJournal(t)
+			tt.run(t)
+			check(t, "after the operation", tt.after)
+
+			if err := undoOnce(t); err != nil {
+				t.Fatalf("undo: %v", err)
+			}
+			check(t, "after undo", tt.undone)
+			if journalPos != 0 {
+				t.Errorf("after undo: journal position = %d, want 0", journalPos)
+			}
+
+			if err := redoOnce(t); err != nil {
+				t.Fatalf("redo: %v", err)
+			}
+			check(t, "after redo", tt.after)
+			if journalPos != 1 {
+				t.Errorf("after redo: journal position = %d, want 1", journalPos)
+			}
+
+			if err := undoOnce(t); err != nil {
+				t.Fatalf("second undo: %v", err)
+			}
+			check(t, "after second undo", tt.undone)
+		})
+	}
+}
+
+func TestUndoLinkSeparatesFiles(t *testing.T) {
+	setupJournal(t)
+	writeFile(t, "keep.txt", "same")
+	writeFile(t, "dup.txt", "same")
+	before := journalSnap{State: statePath("dup.txt")}
+	if err := linkDupe("keep.txt", "dup.txt"); err != nil {
+		t.Fatal(err)
+	}
+	recordJournal(journalLink, "gxdupes", "dup.txt", "keep.txt", before)
+
+	if err := undoOnce(t); err != nil {
+		t.Fatal(err)
+	}
+	keep, _ := os.Stat("keep.txt")
+	dup, _ := os.Stat("dup.txt")
+	if os.SameFile(keep, dup) {
+		t.Error("dup.txt is still a hard link to keep.txt after undo")
+	}
+}
+
+func TestUndoRefusesChangedFile(t *testing.T) {
+	tests := []struct {
+		name string
+		// run performs and records the operation, then changes the result
+		run  func(t *testing.T)
+		path string
+		want string
+	}{
+		{
+			name: "modified again",
+			run: func(t *testing.T) {
+				writeFile(t, "m.txt", "old")
+				before := journalCapture("m.txt")
+				writeFile(t, "m.txt", "new")
+				recordJournal(journalModify, "gxreplace", "m.txt", "", before)
+				writeFile(t, "m.txt", "edited later")
+			},
+			path: "m.txt",
+			want: "edited later",
+		},
+		{
+			name: "created file edited",
+			run: func(t *testing.T) {
+				writeFile(t, "c.txt", "")
+				recordJournal(journalCreate, "gxtouch", "c.txt", "", journalSnap{})
+				writeFile(t, "c.txt", "work in progress")
+			},
+			path: "c.txt",
+			want: "work in progress",
+		},
+		{
+			name: "source of move recreated",
+			run: func(t *testing.T) {
+				writeFile(t, "a.txt", "moved")
+				before := journalCapture("b.txt")
+				if err := movePath("a.txt", "b.txt"); err != nil {
+					t.Fatal(err)
+				}
+				recordJournal(journalMove, "gxmv", "b.txt", "a.txt", before)
+				writeFile(t, "a.txt", "replacement")
+			},
+			path: "a.txt",
+			want: "replacement",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupJournal(t)
+			tt.run(t)
+
+			if err := undoOnce(t); err == nil {
+				t.Fatal("undo succeeded, want it refused")
+			}
+			if got := fileContents(t, tt.path); got != tt.want {
+				t.Errorf("after refused undo: %s = %q, want %q", tt.path, got, tt.want)
+			}
+			if journalPos != 1 {
+				t.Errorf("after refused undo: journal position = %d, want 1", journalPos)
+			}
+		})
+	}
+}
+
+func TestUndoForceOverridesChange(t *testing.T) {
+	setupJournal(t)
+	writeFile(t, "m.txt", "old")
+	before := journalCapture("m.txt")
+	writeFile(t, "m.txt", "new")
+	recordJournal(journalModify, "gxreplace", "m.txt", "", before)
+	writeFile(t, "m.txt", "edited later")
+
+	if err := undoOnce(t, "--force"); err != nil {
+		t.Fatal(err)
+	}
+	if got := fileContents(t, "m.txt"); got != "old" {
+		t.Errorf("m.txt = %q, want %q", got, "old")
+	}
+}
+
+func TestRecordDiscardsRedoHistory(t *testing.T) {
+	dir := setupJournal(t)
+	writeFile(t, "m.txt", "one")
+	before := journalCapture("m.txt")
+	writeFile(t, "m.txt", "two")
+	recordJournal(journalModify, "gxreplace", "m.txt", "", before)
+	stash := journal[0].After.Stash
+
+	if err := undoOnce(t); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, "other.txt", "x")
+	recordJournal(journalCreate, "gxtouch", "other.txt", "", journalSnap{})
+
+	if len(journal) != 1 || journal[0].Path != filepath.Join(dir, "other.txt") {
+		t.Fatalf("journal = %d entries, want only the new one", len(journal))
+	}
+	if _, err := os.Stat(stash); !os.IsNotExist(err) {
+		t.Errorf("stash %s of the discarded entry still exists", stash)
+	}
+	var err error
+	out := captureRun(t, func() { _, err = gxredo(nil) })
+	if err != errCancelled || !strings.Contains(out, "Nothing to redo") {
+		t.Errorf("redo after a new operation = %v, %q; want nothing to redo", err, out)
+	}
+}
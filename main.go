@@ -7,21 +7,40 @@ import (
 	"strings"
 )
 
+// inputScanner reads commands (and confirmation answers) from standard input
+var inputScanner = bufio.NewScanner(os.Stdin)
+
+// exitStatus is the status of the last command; gx exits with it when its
+// input ends, as a shell running a script does. Commands that check
+// something, such as gxverify, set it to 1 when the check fails.
+var exitStatus int
+
 // main initializes and runs the GX-Shell interactive environment
 func main() {
-	displayWelcome()
+	for _, arg := range os.Args[1:] {
+		switch arg {
+		case "--dry-run":
+			session.dryRun = true
+		case "-y", "--yes":
+			session.assumeYes = true
+		}
+	}
 
-	scanner := bufio.NewScanner(os.Stdin)
+	displayWelcome()
 
 	for {
 		cwd, _ := os.Getwd()
-		fmt.Printf("\n%s\ngx-shell> ", cwd)
+		prompt := "gx-shell> "
+		if session.dryRun {
+			prompt = "gx-shell (dry-run)> "
+		}
+		fmt.Printf("\n%s\n%s", cwd, prompt)
 
-		if !scanner.Scan() {
+		if !inputScanner.Scan() {
 			break
 		}
 
-		input := scanner.Text()
+		input := inputScanner.Text()
 		parts := strings.Fields(input)
 
 		if len(parts) == 0 {
@@ -35,8 +54,35 @@ func main() {
 			break
 		}
 
-		handleCommand(command, parts)
+		exitStatus = 0
+		parts, target, appendTo, err := splitRedirect(parts)
+		if err != nil {
+			fmt.Println("Error:", err)
+			continue
+		}
+		parts, sink, err := splitPipe(parts)
+		if err != nil {
+			fmt.Println("Error:", err)
+			continue
+		}
+		run := func() {
+			if sink != nil {
+				runPipe(parts, sink)
+			} else {
+				handleCommand(command, parts)
+			}
+		}
+		if target != "" {
+			_, current = extractGlobalFlags(parts)
+			n, err := redirectOutput(command, target, appendTo, run)
+			recordAudit("redirect", append([]string{command}, auditPaths(target)...), n, err)
+			continue
+		}
+		run()
 	}
+
+	clearJournal()
+	os.Exit(exitStatus)
 }
 
 // displayWelcome shows the welcome message and available commands
@@ -44,20 +90,29 @@ func displayWelcome() {
 	fmt.Println("--- Gopher Shell (GX) V3.5 Activated ---")
 	fmt.Println("=== File Operations ===")
 	fmt.Println("gx  [name]        : Create File/Folder")
-	fmt.Println("gxd [name]        : Delete")
+	fmt.Println("gxd [name]        : Delete (to trash, --force to erase)")
 	fmt.Println("gxc [path]        : Change Directory (cd)")
 	fmt.Println("gxl               : List Files (ls)")
 	fmt.Println("gxs [name]        : Check Storage Size")
 	fmt.Println("gxmv [src] [dst]  : Move/Rename file")
-	fmt.Println("gxcp [src] [dst]  : Copy file")
-	fmt.Println("gxfind [name]     : Find files by name")
+	fmt.Println("gxcp [-r] [src] [dst] : Copy file or directory tree")
+	fmt.Println("gxfind [name]     : Find files by name (or -type/-size/-mtime/-name ...)")
+	fmt.Println("gxfzf [pattern]   : Fuzzy-find files (--cat / --cd to open the pick)")
+	fmt.Println("gxindex build     : Index this directory for instant gxfind/gxfzf")
 	fmt.Println("gxecho [text] [file] : Write text to file")
 	fmt.Println("gxdup [file]      : Duplicate file")
 	fmt.Println("\n=== File Viewing ===")
-	fmt.Println("gxcat [file]      : View file contents")
-	fmt.Println("gxhead [file]     : View first 10 lines")
-	fmt.Println("gxtail [file]     : View last 10 lines")
-	fmt.Println("gxgrep [text] [file] : Search text in file")
+	fmt.Println("gxcat [file]      : View file contents (-n numbers, file:120-180 for a range)")
+	fmt.Println("gxhead [file]     : View first 10 lines (-n N, -c BYTES)")
+	fmt.Println("gxtail [file]     : View last 10 lines (-n N, -f to follow)")
+	fmt.Println("gxless [file]     : Page through a file (or: [command] | gxless)")
+	fmt.Println("gxhash [files]    : SHA-256 checksums in sha256sum format (-a md5|sha1|sha512|blake2b|crc32)")
+	fmt.Println("gxverify [sums]   : Check files against a checksum file (gxhash -r dir > SHA256SUMS)")
+	fmt.Println("gxdupes [dir]     : Find duplicate files (--link or --trash to reclaim the space)")
+	fmt.Println("gxfingerprint [dir] : Hash a whole tree (--save snap.json; gxchanged snap.json lists changes)")
+	fmt.Println("gxhex [file]      : Hex dump (-s OFFSET, -n LENGTH, -d to diff two files)")
+	fmt.Println("gxiconv [files]   : Show or convert encodings (-t utf-8) and line endings (--lf/--crlf)")
+	fmt.Println("gxgrep [text] [files...] : Search text in files (-e regex, -r, -C N)")
 	fmt.Println("gxstat [file]     : Show file statistics")
 	fmt.Println("\n=== System Info ===")
 	fmt.Println("gxpwd             : Print working directory")
@@ -70,8 +125,18 @@ func displayWelcome() {
 	fmt.Println("gxempty [name]    : Create empty file")
 	fmt.Println("gxmkdir [name]    : Create directory (mkdir)")
 	fmt.Println("gxtouch [file]    : Update file timestamp")
+	fmt.Println("gxreplace [old] [new] [files] : Replace text (regex with -e)")
+	fmt.Println("gxtruncate [file] [bytes]    : Truncate file")
+	fmt.Println("gxrenameext [file] [ext]     : Change file extension")
+	fmt.Println("gxbackup [file]   : Create timestamped backup")
+	fmt.Println("gxtrash [list|restore|empty] : Manage the trash")
+	fmt.Println("gxundo / gxredo   : Undo or redo the last file operation")
+	fmt.Println("gxjournal         : List undoable operations")
+	fmt.Println("gxaudit [verify]  : Query/verify the audit log")
+	fmt.Println("gxset [option] [on|off]      : Toggle dry-run / confirmations")
 	fmt.Println("gxhelp            : Show extended help")
-	fmt.Println("\nType 'exit' or press Ctrl+X then Enter to quit")
+	fmt.Println("\nAdd -y to skip confirmation or --dry-run to preview, right after the command name")
+	fmt.Println("Type 'exit' or press Ctrl+X then Enter to quit")
 	fmt.Println("--------------------------------------")
 }
 
@@ -83,6 +148,8 @@ func handleCommand(command string, parts []string) {
 		return
 	}
 
+	parts, current = extractGlobalFlags(parts)
+
 	switch command {
 	// File Operations
 	case "gx":
@@ -90,14 +157,26 @@ func handleCommand(command string, parts []string) {
 			fmt.Println("Error: Missing name")
 			return
 		}
-		createItem(parts[1])
+		n, err := createItem(parts[1])
+		recordAudit(command, auditPaths(parts[1]), n, err)
 
 	case "gxd":
-		if len(parts) < 2 {
+		args := parts[1:]
+		force := len(args) > 0 && args[0] == "--force"
+		if force {
+			args = args[1:]
+		}
+		if len(args) < 1 {
 			fmt.Println("Error: Missing name")
+			fmt.Println("Usage: gxd [--force] [name]")
 			return
 		}
-		deleteItem(parts[1])
+		n, err := deleteItem(args[0], force)
+		auditArgs := auditPaths(args[0])
+		if force {
+			auditArgs = append([]string{"--force"}, auditArgs...)
+		}
+		recordAudit(command, auditArgs, n, err)
 
 	case "gxc":
 		if len(parts) < 2 {
@@ -122,22 +201,52 @@ func handleCommand(command string, parts []string) {
 			fmt.Println("Usage: gxmv [source] [destination]")
 			return
 		}
-		moveFile(parts[1], parts[2])
+		n, err := moveFile(parts[1], parts[2])
+		recordAudit(command, auditPaths(parts[1], parts[2]), n, err)
 
 	case "gxcp":
-		if len(parts) < 3 {
+		opts, args, err := parseCopyArgs(parts[1:])
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if len(args) < 2 {
 			fmt.Println("Error: Missing source or destination")
-			fmt.Println("Usage: gxcp [source] [destination]")
+			fmt.Println("Usage: gxcp [-r] [--overwrite never|always|if-newer] [source] [destination]")
 			return
 		}
-		copyFile(parts[1], parts[2])
+		n, err := copyFile(args[0], args[1], opts)
+		recordAudit(command, auditPaths(args[0], args[1]), n, err)
 
 	case "gxfind":
 		if len(parts) < 2 {
 			fmt.Println("Error: Missing filename to search for")
+			fmt.Println("Usage: gxfind [name] | gxfind [paths...] [-type f|d|l] [-name glob] [-size +10M] [-mtime -2d] ...")
+			return
+		}
+		opts, err := parseFindArgs(parts[1:])
+		if err != nil {
+			if err != errInvalidInput {
+				fmt.Println("Error:", err)
+			}
 			return
 		}
-		findFiles(parts[1])
+		n, err := gxfind(opts)
+		if opts.delete {
+			recordAudit(command, append(auditPaths(opts.paths...), parts[1+len(opts.paths):]...), n, err)
+		}
+
+	case "gxfzf":
+		opts, err := parseFuzzyArgs(parts[1:])
+		if err != nil {
+			fmt.Println("Error:", err)
+			fmt.Println("Usage: gxfzf [-n N] [--dirs] [--hidden] [--cat|--cd] [pattern]")
+			return
+		}
+		gxfzf(opts)
+
+	case "gxindex":
+		gxindex(parts[1:])
 
 	case "gxecho":
 		if len(parts) < 3 {
@@ -145,44 +254,148 @@ func handleCommand(command string, parts []string) {
 			fmt.Println("Usage: gxecho [text] [filename]")
 			return
 		}
-		echoToFile(parts[1], parts[2])
+		n, err := echoToFile(parts[1], parts[2])
+		recordAudit(command, append([]string{parts[1]}, auditPaths(parts[2])...), n, err)
 
 	case "gxdup":
 		if len(parts) < 2 {
 			fmt.Println("Error: Missing filename")
 			return
 		}
-		duplicateFile(parts[1])
+		n, err := duplicateFile(parts[1])
+		recordAudit(command, auditPaths(parts[1]), n, err)
 
 	// File Viewing
 	case "gxcat":
+		opts, files, err := parseViewArgs(command, parts[1:])
+		if err != nil {
+			fmt.Println("Error:", err)
+			fmt.Println("Usage: gxcat [-n] [-A] [filename[:FROM-TO]...]")
+			return
+		}
+		for _, arg := range files {
+			name, from, to, err := parseLineRange(arg)
+			if err != nil {
+				fmt.Println("Error:", err)
+				continue
+			}
+			fileOpts := opts
+			fileOpts.from, fileOpts.to = from, to
+			viewFile(name, fileOpts)
+		}
+
+	case "gxhex":
+		opts, err := parseHexArgs(parts[1:])
+		if err != nil {
+			fmt.Println("Error:", err)
+			fmt.Println("Usage: gxhex [-s OFFSET] [-n LENGTH] [-v] [filename] | gxhex -d [file1] [file2]")
+			return
+		}
+		gxhex(opts)
+
+	case "gxless":
 		if len(parts) < 2 {
 			fmt.Println("Error: Missing filename")
+			fmt.Println("Usage: gxless [filename] | [command] | gxless")
 			return
 		}
-		viewFile(parts[1])
+		pageFile(parts[1])
 
 	case "gxhead":
-		if len(parts) < 2 {
-			fmt.Println("Error: Missing filename")
+		opts, files, err := parseViewArgs(command, parts[1:])
+		if err != nil {
+			fmt.Println("Error:", err)
+			fmt.Println("Usage: gxhead [-n N] [-c BYTES] [-A] [filename...]")
 			return
 		}
-		headFile(parts[1], 10)
+		for _, file := range files {
+			headFile(file, opts)
+		}
 
 	case "gxtail":
-		if len(parts) < 2 {
-			fmt.Println("Error: Missing filename")
+		opts, files, err := parseViewArgs(command, parts[1:])
+		if err != nil {
+			fmt.Println("Error:", err)
+			fmt.Println("Usage: gxtail [-f] [-n N] [-c BYTES] [-A] [filename...]")
 			return
 		}
-		tailFile(parts[1], 10)
+		if opts.follow {
+			followFiles(files, opts)
+			return
+		}
+		for _, file := range files {
+			tailFile(file, opts)
+		}
 
 	case "gxgrep":
-		if len(parts) < 3 {
-			fmt.Println("Error: Missing search text or filename")
-			fmt.Println("Usage: gxgrep [search text] [filename]")
+		opts, err := parseGrepArgs(parts[1:])
+		if err != nil {
+			fmt.Println("Error:", err)
+			fmt.Println("Usage: gxgrep [-e] [-s] [-w] [-v] [-c] [-l] [-r] [-A/-B/-C N] [text] [files/dirs...]")
 			return
 		}
-		grepFile(parts[1], parts[2])
+		grepFile(opts)
+
+	case "gxhash", "gxmd5", "gxsha1":
+		opts, err := parseHashArgs(command, parts[1:])
+		if err == nil && len(opts.patterns) == 0 {
+			err = fmt.Errorf("missing filename")
+		}
+		if err != nil {
+			fmt.Println("Error:", err)
+			fmt.Printf("Usage: %s [-a ALG] [--tag] [-r] [-j N] [files/globs/dirs...]\n", command)
+			return
+		}
+		files, err := collectHashFiles(opts)
+		if err != nil {
+			if err != errInvalidInput {
+				fmt.Println("Error:", err)
+			}
+			return
+		}
+		gxhash(opts, files)
+
+	case "gxverify":
+		opts, err := parseVerifyArgs(parts[1:])
+		if err != nil {
+			fmt.Println("Error:", err)
+			fmt.Println("Usage: gxverify [-a ALG] [-q] [-j N] [checksum files...]")
+			exitStatus = 1
+			return
+		}
+		gxverify(opts)
+
+	case "gxdupes":
+		opts, err := parseDupesArgs(parts[1:])
+		if err != nil {
+			fmt.Println("Error:", err)
+			fmt.Println("Usage: gxdupes [--link|--trash] [-j N] [dirs...]")
+			return
+		}
+		n, err := gxdupes(opts)
+		if opts.link || opts.trash {
+			recordAudit(command, auditPaths(opts.dirs...), n, err)
+		}
+
+	case "gxfingerprint":
+		opts, err := parseFingerprintArgs(parts[1:])
+		if err != nil {
+			fmt.Println("Error:", err)
+			fmt.Println("Usage: gxfingerprint [--save snapshot.json] [-j N] [dirs...]")
+			return
+		}
+		n, err := gxfingerprint(opts)
+		if opts.save != "" {
+			recordAudit(command, auditPaths(opts.save), n, err)
+		}
+
+	case "gxchanged":
+		if len(parts) < 2 || len(parts) > 3 {
+			fmt.Println("Error: Missing snapshot file")
+			fmt.Println("Usage: gxchanged [snapshot.json] [dir]")
+			return
+		}
+		gxchanged(parts[1:])
 
 	case "gxstat":
 		if len(parts) < 2 {
@@ -228,21 +441,108 @@ func handleCommand(command string, parts []string) {
 			fmt.Println("Error: Missing filename")
 			return
 		}
-		createEmptyFile(parts[1])
+		n, err := createEmptyFile(parts[1])
+		recordAudit(command, auditPaths(parts[1]), n, err)
 
 	case "gxmkdir":
 		if len(parts) < 2 {
 			fmt.Println("Error: Missing directory name")
 			return
 		}
-		createDirectory(parts[1])
+		n, err := createDirectory(parts[1])
+		recordAudit(command, auditPaths(parts[1]), n, err)
 
 	case "gxtouch":
 		if len(parts) < 2 {
 			fmt.Println("Error: Missing filename")
 			return
 		}
-		touchFile(parts[1])
+		n, err := touchFile(parts[1])
+		recordAudit(command, auditPaths(parts[1]), n, err)
+
+	case "gxreplace":
+		opts, err := parseReplaceArgs(parts[1:])
+		if err != nil {
+			fmt.Println("Error: Missing arguments")
+			fmt.Println("Usage: gxreplace [-e] [-i] [-w] [old] [new] [files/globs...]")
+			return
+		}
+		files, err := expandFileArgs(opts.patterns)
+		if err != nil {
+			if err != errInvalidInput {
+				fmt.Println("Error:", err)
+			}
+			return
+		}
+		n, err := gxreplace(opts, files)
+		recordAudit(command, append([]string{opts.old, opts.new}, auditPaths(files...)...), n, err)
+
+	case "gxiconv":
+		opts, err := parseIconvArgs(parts[1:])
+		if err != nil {
+			fmt.Println("Error:", err)
+			fmt.Println("Usage: gxiconv [-f FROM] [-t TO] [--lf|--crlf] [files/globs...]")
+			return
+		}
+		files, err := expandFileArgs(opts.patterns)
+		if err != nil {
+			if err != errInvalidInput {
+				fmt.Println("Error:", err)
+			}
+			return
+		}
+		if opts.to == "" && opts.eol == "" {
+			gxiconv(opts, files)
+			return
+		}
+		n, err := gxiconv(opts, files)
+		recordAudit(command, auditPaths(files...), n, err)
+
+	case "gxtruncate":
+		if len(parts) < 3 {
+			fmt.Println("Error: Missing filename or size")
+			fmt.Println("Usage: gxtruncate [filename] [bytes]")
+			return
+		}
+		n, err := gxtruncate(parts[1], parts[2])
+		recordAudit(command, append(auditPaths(parts[1]), parts[2]), n, err)
+
+	case "gxrenameext":
+		if len(parts) < 3 {
+			fmt.Println("Error: Missing filename or extension")
+			fmt.Println("Usage: gxrenameext [filename] [ext]")
+			return
+		}
+		n, err := gxrenameext(parts[1], parts[2])
+		recordAudit(command, append(auditPaths(parts[1]), parts[2]), n, err)
+
+	case "gxbackup":
+		if len(parts) < 2 {
+			fmt.Println("Error: Missing filename")
+			return
+		}
+		n, err := gxbackup(parts[1])
+		recordAudit(command, auditPaths(parts[1]), n, err)
+
+	case "gxtrash":
+		gxtrash(parts[1:])
+
+	case "gxundo":
+		n, err := gxundo(parts[1:])
+		recordAudit(command, parts[1:], n, err)
+
+	case "gxredo":
+		n, err := gxredo(parts[1:])
+		recordAudit(command, parts[1:], n, err)
+
+	case "gxjournal":
+		gxjournal()
+
+	case "gxset":
+		gxset(parts[1:])
+
+	case "gxaudit":
+		gxaudit(parts[1:])
 
 	// Help
 	case "gxhelp":
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ==================== MOVING ====================
+
+// isCrossDevice reports whether a rename failed because source and
+// destination are on different filesystems (EXDEV)
+func isCrossDevice(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
+
+// movePath renames src to dst, falling back to copy-verify-delete when
+// they are on different filesystems
+func movePath(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !isCrossDevice(err) {
+		return err
+	}
+	return moveAcrossDevices(src, dst)
+}
+
+// moveAcrossDevices copies src (file or tree, with metadata) into a temporary
+// name next to dst, verifies the copy, renames it into place and only then
+// removes the source. Any failure before the rename removes the partial copy
+// and leaves the source untouched.
+func moveAcrossDevices(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(filepath.Dir(dst), fmt.Sprintf(".gxmv-%d-%s", os.Getpid(), filepath.Base(dst)))
+	if _, err := os.Lstat(tmp); err == nil {
+		return fmt.Errorf("temporary path '%s' already exists", tmp)
+	}
+
+	files, bytes := treeTotals(src)
+	stats := copyStats{prog: newProgress("Moving (cross-device)", bytes, files)}
+	err = copyEntry(src, tmp, info, copyOptions{recursive: true, overwrite: overwriteAlways}, &stats)
+	stats.prog.finish()
+
+	if err == nil {
+		err = verifyCopy(src, tmp)
+	}
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		os.RemoveAll(tmp)
+		return fmt.Errorf("cross-device move failed, source left untouched: %w", err)
+	}
+
+	if err := os.RemoveAll(src); err != nil {
+		return fmt.Errorf("copied to '%s' but could not remove the source: %w", dst, err)
+	}
+	return nil
+}
+
+// verifyCopy checks that every entry under src exists under dst with the same
+// type, contents and symlink target
+func verifyCopy(src, dst string) error {
+	files, bytes := treeTotals(src)
+	prog := newProgress("Verifying", bytes*2, files)
+	defer prog.finish()
+
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		copied, err := os.Lstat(target)
+		if err != nil {
+			return fmt.Errorf("verification failed: %w", err)
+		}
+		if copied.Mode().Type() != info.Mode().Type() {
+			return fmt.Errorf("verification failed: '%s' has a different type", target)
+		}
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			want, _ := os.Readlink(path)
+			got, _ := os.Readlink(target)
+			if want != got {
+				return fmt.Errorf("verification failed: link '%s' points elsewhere", target)
+			}
+		case info.Mode().IsRegular():
+			if !sameState(statePath(path), statePath(target)) {
+				return fmt.Errorf("verification failed: contents of '%s' differ", target)
+			}
+			prog.addBytes(info.Size() * 2)
+			prog.addFile()
+		}
+		return nil
+	})
+}
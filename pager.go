@@ -0,0 +1,604 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// ==================== PAGER ====================
+
+const (
+	pagerMaxLine  = 64 * 1024 // longer lines are cut when displayed
+	pagerTabWidth = 8
+)
+
+// pagerEnabled controls whether long output is paged automatically (gxset pager)
+var pagerEnabled = os.Getenv("GX_PAGER") != "off"
+
+// captureStdout is the real terminal while a command's output is being
+// captured for "| gxless"; prompts are written there so they stay visible
+var captureStdout *os.File
+
+// promptOut returns where interactive prompts should be written
+func promptOut() io.Writer {
+	if captureStdout != nil {
+		return captureStdout
+	}
+	return os.Stdout
+}
+
+// pagerSource provides the lines shown by the pager
+type pagerSource interface {
+	// line returns line i (0-based), or false past the end
+	line(i int) (string, bool)
+	// known returns how many lines have been seen and whether that is all of them
+	known() (int, bool)
+}
+
+// bufferSource pages text held in memory
+type bufferSource struct {
+	lines []string
+}
+
+func newBufferSource(text string) *bufferSource {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return &bufferSource{}
+	}
+	return &bufferSource{lines: strings.Split(text, "\n")}
+}
+
+func (b *bufferSource) line(i int) (string, bool) {
+	if i < 0 || i >= len(b.lines) {
+		return "", false
+	}
+	return b.lines[i], true
+}
+
+func (b *bufferSource) known() (int, bool) { return len(b.lines), true }
+
+// fileSource pages a file without loading it: line start offsets are
+// recorded lazily as the pager moves further into the file
+type fileSource struct {
+	file    *os.File
+	reader  *bufio.Reader
+	offsets []int64 // start of each line seen so far
+	pos     int64   // how far the scan has read
+	done    bool
+}
+
+func newFileSource(file *os.File) *fileSource {
+	// Scan through a section reader so the file's own offset is left alone
+	scan := io.NewSectionReader(file, 0, 1<<62)
+	return &fileSource{file: file, reader: bufio.NewReaderSize(scan, 64*1024)}
+}
+
+// scanTo records line offsets until line i is known or the file ends
+func (f *fileSource) scanTo(i int) {
+	for !f.done && len(f.offsets) <= i+1 {
+		start := f.pos
+		for {
+			chunk, err := f.reader.ReadSlice('\n')
+			f.pos += int64(len(chunk))
+			if err == bufio.ErrBufferFull {
+				continue
+			}
+			if err != nil {
+				f.done = true
+			}
+			break
+		}
+		if f.pos > start {
+			f.offsets = append(f.offsets, start)
+		}
+	}
+}
+
+func (f *fileSource) line(i int) (string, bool) {
+	if i < 0 {
+		return "", false
+	}
+	f.scanTo(i)
+	if i >= len(f.offsets) {
+		return "", false
+	}
+	end := f.pos
+	if i+1 < len(f.offsets) {
+		end = f.offsets[i+1]
+	}
+	size := min(end-f.offsets[i], pagerMaxLine)
+	buf := make([]byte, size)
+	n, _ := f.file.ReadAt(buf, f.offsets[i])
+	return strings.TrimRight(string(buf[:n]), "\r\n"), true
+}
+
+func (f *fileSource) known() (int, bool) { return len(f.offsets), f.done }
+
+// pager is the state of one interactive paging session
+type pager struct {
+	title   string
+	src     pagerSource
+	top     int // first visible line
+	left    int // first visible column
+	numbers bool
+	search  *regexp.Regexp
+	message string
+	width   int
+	height  int
+	in      *bufio.Reader
+	lex     *lexer     // syntax highlighting, or nil
+	states  []lexState // lexer state at the start of each line tokenized so far
+}
+
+// terminalSize returns the terminal's columns and rows, with safe fallbacks
+func terminalSize() (int, int) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// canPage reports whether both ends of the session are a terminal
+func canPage() bool {
+	return pagerEnabled && term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// needsPaging reports whether src has more lines than fit on the screen.
+// The pager keeps its last row for the status line (see pageRows), so
+// lines 0 to height-2 fit and only a line at height-1 needs paging.
+func needsPaging(src pagerSource) bool {
+	_, height := terminalSize()
+	_, ok := src.line(height - 1)
+	return ok
+}
+
+// runPager shows src full-screen until the user quits. When lex is not nil
+// the lines are syntax highlighted.
+func runPager(title string, src pagerSource, lex *lexer) error {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	// Alternate screen, so the shell's scrollback is left as it was
+	fmt.Print("\033[?1049h\033[?25l")
+	defer fmt.Print("\033[?25h\033[?1049l")
+
+	p := &pager{title: title, src: src, in: bufio.NewReader(os.Stdin)}
+	if lex != nil && themeName != "none" {
+		p.lex, p.states = lex, []lexState{lexNormal}
+	}
+	for {
+		p.width, p.height = terminalSize()
+		p.render()
+		key, err := p.readKey()
+		if err != nil {
+			return nil
+		}
+		if !p.handleKey(key) {
+			return nil
+		}
+	}
+}
+
+// pageRows is the number of text rows (the last row is the status line)
+func (p *pager) pageRows() int {
+	return max(p.height-1, 1)
+}
+
+// render draws the visible lines and the status line
+func (p *pager) render() {
+	var b strings.Builder
+	b.WriteString("\033[H\033[2J")
+
+	rows := p.pageRows()
+	gutter := 0
+	if p.numbers {
+		gutter = 8
+	}
+	shown := 0
+	for row := 0; row < rows; row++ {
+		line, ok := p.src.line(p.top + row)
+		if !ok {
+			b.WriteString("\033[2m~\033[0m\r\n")
+			continue
+		}
+		shown++
+		if p.numbers {
+			fmt.Fprintf(&b, "\033[2m%7d\033[0m ", p.top+row+1)
+		}
+		b.WriteString(p.visible(line, p.width-gutter, p.lineSpans(p.top+row, line)))
+		b.WriteString("\r\n")
+	}
+
+	total, complete := p.src.known()
+	count := strconv.Itoa(total)
+	if !complete {
+		count += "+"
+	}
+	status := fmt.Sprintf(" %s  lines %d-%d of %s", p.title, p.top+1, p.top+max(shown, 1), count)
+	if p.left > 0 {
+		status += fmt.Sprintf("  col %d", p.left+1)
+	}
+	if p.message != "" {
+		status += "  " + p.message
+		p.message = ""
+	} else {
+		status += "  (q quit, / search, n/N next/prev, :N jump, # numbers, ←→ scroll)"
+	}
+	if utf8.RuneCountInString(status) > p.width {
+		status = string([]rune(status)[:p.width])
+	}
+	b.WriteString("\033[7m" + status + "\033[0m")
+	os.Stdout.WriteString(b.String())
+}
+
+// lineSpans returns the syntax spans of line i, tokenizing any earlier lines
+// not seen yet so that multi-line comments and strings carry over
+func (p *pager) lineSpans(i int, line string) []span {
+	if p.lex == nil {
+		return nil
+	}
+	for len(p.states) <= i {
+		prev, ok := p.src.line(len(p.states) - 1)
+		if !ok {
+			return nil
+		}
+		st := p.states[len(p.states)-1]
+		p.lex.spans(p.lex, prev, &st)
+		p.states = append(p.states, st)
+	}
+	st := p.states[i]
+	return p.lex.spans(p.lex, line, &st)
+}
+
+// visible expands tabs, hides control characters, applies the horizontal
+// scroll and colors syntax spans and search matches within the part that fits
+func (p *pager) visible(line string, width int, spans []span) string {
+	var expanded []rune
+	var kinds []tokenKind
+	si := 0
+	for off, r := range line {
+		kind := tokText
+		for si < len(spans) && spans[si].end <= off {
+			si++
+		}
+		if si < len(spans) && spans[si].start <= off {
+			kind = spans[si].kind
+		}
+		switch {
+		case r == '\t':
+			for n := pagerTabWidth - len(expanded)%pagerTabWidth; n > 0; n-- {
+				expanded = append(expanded, ' ')
+				kinds = append(kinds, kind)
+			}
+		case unicode.IsControl(r):
+			expanded = append(expanded, '?')
+			kinds = append(kinds, kind)
+		default:
+			expanded = append(expanded, r)
+			kinds = append(kinds, kind)
+		}
+	}
+	if p.left >= len(expanded) {
+		return ""
+	}
+	expanded, kinds = expanded[p.left:], kinds[p.left:]
+	if len(expanded) > width {
+		expanded, kinds = expanded[:max(width, 0)], kinds[:max(width, 0)]
+	}
+	text := string(expanded)
+	if p.search == nil && p.lex == nil {
+		return text
+	}
+
+	// Search matches are shown in reverse video over any syntax color
+	matched := make([]bool, len(expanded))
+	if p.search != nil {
+		runeAt := make(map[int]int, len(expanded))
+		n := 0
+		for off := range text {
+			runeAt[off] = n
+			n++
+		}
+		runeAt[len(text)] = n
+		for _, m := range p.search.FindAllStringIndex(text, -1) {
+			for j := runeAt[m[0]]; j < runeAt[m[1]]; j++ {
+				matched[j] = true
+			}
+		}
+	}
+
+	var b strings.Builder
+	current := ""
+	for j, r := range expanded {
+		style := themeColor(kinds[j])
+		if matched[j] {
+			style = "\033[7m"
+		}
+		if style != current {
+			if current != "" {
+				b.WriteString(colorReset)
+			}
+			b.WriteString(style)
+			current = style
+		}
+		b.WriteRune(r)
+	}
+	if current != "" {
+		b.WriteString(colorReset)
+	}
+	return b.String()
+}
+
+// Keys that arrive as escape sequences
+const (
+	keyUp = -(iota + 1)
+	keyDown
+	keyLeft
+	keyRight
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+)
+
+// readKey reads one key press, decoding arrow and paging keys
+func (p *pager) readKey() (rune, error) {
+	r, _, err := p.in.ReadRune()
+	if err != nil || r != 0x1b {
+		return r, err
+	}
+	if p.in.Buffered() == 0 {
+		return r, nil // a lone Escape
+	}
+	next, _, _ := p.in.ReadRune()
+	if next != '[' && next != 'O' {
+		return next, nil
+	}
+	code, _, _ := p.in.ReadRune()
+	switch code {
+	case 'A':
+		return keyUp, nil
+	case 'B':
+		return keyDown, nil
+	case 'C':
+		return keyRight, nil
+	case 'D':
+		return keyLeft, nil
+	case 'H':
+		return keyHome, nil
+	case 'F':
+		return keyEnd, nil
+	case '1', '4', '5', '6', '7', '8':
+		p.in.ReadRune() // the trailing '~'
+		switch code {
+		case '5':
+			return keyPageUp, nil
+		case '6':
+			return keyPageDown, nil
+		case '1', '7':
+			return keyHome, nil
+		}
+		return keyEnd, nil
+	}
+	return 0, nil
+}
+
+// prompt reads a line of input on the status row (Escape cancels)
+func (p *pager) prompt(label string) (string, bool) {
+	var input []rune
+	for {
+		fmt.Printf("\033[%d;1H\033[2K%s%s", p.height, label, string(input))
+		r, _, err := p.in.ReadRune()
+		if err != nil {
+			return "", false
+		}
+		switch {
+		case r == '\r' || r == '\n':
+			return string(input), true
+		case r == 0x1b || r == 3:
+			return "", false
+		case r == 0x7f || r == 8:
+			if len(input) == 0 {
+				return "", false
+			}
+			input = input[:len(input)-1]
+		case !unicode.IsControl(r):
+			input = append(input, r)
+		}
+	}
+}
+
+// scrollTo moves the view so line n is at the top, keeping the last page full
+func (p *pager) scrollTo(n int) {
+	p.top = max(n, 0)
+	if _, ok := p.src.line(p.top); ok {
+		return
+	}
+	// Past the end: show the final page
+	p.src.line(p.top + p.pageRows())
+	total, _ := p.src.known()
+	p.top = max(total-p.pageRows(), 0)
+}
+
+// find searches from line start in the given direction and jumps to the match
+func (p *pager) find(start, step int) {
+	if p.search == nil {
+		p.message = "No previous search"
+		return
+	}
+	for i := start; i >= 0; i += step {
+		line, ok := p.src.line(i)
+		if !ok {
+			break
+		}
+		if p.search.MatchString(line) {
+			p.scrollTo(i)
+			return
+		}
+	}
+	p.message = "Pattern not found"
+}
+
+// handleKey applies one key press and returns false when the pager should close
+func (p *pager) handleKey(key rune) bool {
+	rows := p.pageRows()
+	switch key {
+	case 'q', 'Q', 3:
+		return false
+	case 'j', '\r', '\n', 'e', keyDown:
+		p.scrollTo(p.top + 1)
+	case 'k', 'y', keyUp:
+		p.scrollTo(p.top - 1)
+	case ' ', 'f', keyPageDown:
+		p.scrollTo(p.top + rows)
+	case 'b', keyPageUp:
+		p.scrollTo(p.top - rows)
+	case 'd':
+		p.scrollTo(p.top + rows/2)
+	case 'u':
+		p.scrollTo(p.top - rows/2)
+	case 'g', '<', keyHome:
+		p.scrollTo(0)
+	case 'G', '>', keyEnd:
+		p.scrollTo(int(^uint(0) >> 2))
+	case 'h', keyLeft:
+		p.left = max(p.left-p.width/4, 0)
+	case 'l', keyRight:
+		p.left += p.width / 4
+	case '#':
+		p.numbers = !p.numbers
+	case '/', '?':
+		text, ok := p.prompt(string(key))
+		if !ok || text == "" {
+			return true
+		}
+		if !strings.ContainsFunc(text, unicode.IsUpper) {
+			text = "(?i)" + text
+		}
+		re, err := regexp.Compile(text)
+		if err != nil {
+			re = regexp.MustCompile(regexp.QuoteMeta(text))
+		}
+		p.search = re
+		if key == '/' {
+			p.find(p.top+1, 1)
+		} else {
+			p.find(p.top-1, -1)
+		}
+	case 'n':
+		p.find(p.top+1, 1)
+	case 'N':
+		p.find(p.top-1, -1)
+	case ':':
+		text, ok := p.prompt(":")
+		if n, err := strconv.Atoi(strings.TrimSpace(text)); ok && err == nil {
+			p.scrollTo(n - 1)
+		}
+	}
+	return true
+}
+
+// openRegular opens a file that is not a directory, reporting any error and
+// returning a nil file
+func openRegular(filename string) (*os.File, os.FileInfo) {
+	file, err := os.Open(filename)
+	if err != nil {
+		fmt.Printf("Error reading file '%s': %v\n", filename, err)
+		return nil, nil
+	}
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		fmt.Printf("Error reading file '%s': not a regular file\n", filename)
+		file.Close()
+		return nil, nil
+	}
+	return file, info
+}
+
+// openView opens a file for gxcat and gxless. Errors and binary files are
+// reported here and give a nil file; binary files are shown as text instead
+// when allowBinary is set. Text in another encoding is decoded to UTF-8 in
+// memory, and title then names the encoding. The caller closes file.
+func openView(filename string, allowBinary bool) (file *os.File, r io.Reader, src pagerSource, title string, lex *lexer) {
+	file, info := openRegular(filename)
+	if file == nil {
+		return nil, nil, nil, "", nil
+	}
+	enc, binary := sniffText(file)
+	if binary && !allowBinary {
+		showBinaryPreview(filename, file, info.Size())
+		file.Close()
+		return nil, nil, nil, "", nil
+	}
+
+	r, src, title = file, newFileSource(file), filename
+	if enc != encUTF8 {
+		// The decoded text is held in memory once, as a single string that
+		// the pager's lines share
+		if !checkFileSizeLimit(info.Size()) {
+			file.Close()
+			return nil, nil, nil, "", nil
+		}
+		var text strings.Builder
+		if _, err := io.Copy(&text, newDecodeReader(file, enc)); err != nil {
+			fmt.Printf("Error reading file '%s': %v\n", filename, err)
+			file.Close()
+			return nil, nil, nil, "", nil
+		}
+		r, src = strings.NewReader(text.String()), newBufferSource(text.String())
+		title = fmt.Sprintf("%s [%s]", filename, enc)
+	}
+	// The real name picks the highlighter; title may carry the encoding
+	return file, r, src, title, lexerFor(filename, file)
+}
+
+// pageFile shows a file in the pager
+func pageFile(filename string) {
+	file, r, src, title, lex := openView(filename, false)
+	if file == nil {
+		return
+	}
+	defer file.Close()
+
+	if !canPage() {
+		io.Copy(os.Stdout, r)
+		return
+	}
+	if err := runPager(title, src, lex); err != nil {
+		fmt.Printf("Error starting pager: %v\n", err)
+	}
+}
+
+// runPaged runs a command with its output captured and shows the output in
+// the pager when it does not fit on the screen ("command | gxless")
+func runPaged(parts []string) {
+	var captured bytes.Buffer
+	if err := captureOutput(parts, func(r io.Reader) { io.Copy(&captured, r) }); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	src := newBufferSource(captured.String())
+	if !canPage() || !needsPaging(src) {
+		os.Stdout.Write(captured.Bytes())
+		return
+	}
+	if err := runPager(parts[0], src, nil); err != nil {
+		fmt.Printf("Error starting pager: %v\n", err)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNeedsPaging(t *testing.T) {
+	// Tests do not run on a terminal, so terminalSize falls back to 24 rows
+	_, height := terminalSize()
+	tests := []struct {
+		lines int
+		want  bool
+	}{
+		{0, false},
+		{height - 2, false},
+		{height - 1, false}, // exactly fills the rows above the status line
+		{height, true},
+		{height * 3, true},
+	}
+	for _, tt := range tests {
+		src := newBufferSource(strings.Repeat("line\n", tt.lines))
+		if got := needsPaging(src); got != tt.want {
+			t.Errorf("needsPaging(%d lines on %d rows) = %v, want %v", tt.lines, height, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ==================== PIPES ====================
+
+// pipeSinks are the commands that can read another command's output
+var pipeSinks = map[string]bool{
+	"gxless": true,
+	"gxhash": true,
+}
+
+// splitPipe separates "command ... | sink [args]" into the command and the
+// sink; sink is nil when there is no pipe
+func splitPipe(parts []string) ([]string, []string, error) {
+	for i, part := range parts {
+		if part != "|" {
+			continue
+		}
+		sink := parts[i+1:]
+		if len(sink) == 0 || !pipeSinks[sink[0]] {
+			return nil, nil, fmt.Errorf("only '| gxless' and '| gxhash' are supported after a command")
+		}
+		if i == 0 {
+			return nil, nil, fmt.Errorf("missing command before '|'")
+		}
+		if sink[0] == "gxless" && len(sink) > 1 {
+			return nil, nil, fmt.Errorf("gxless takes no arguments after '|'")
+		}
+		return parts[:i], sink, nil
+	}
+	return parts, nil, nil
+}
+
+// runPipe runs "command | sink"
+func runPipe(parts, sink []string) {
+	switch sink[0] {
+	case "gxless":
+		runPaged(parts)
+	case "gxhash":
+		hashPipe(parts, sink[1:])
+	}
+}
+
+// captureOutput runs a command with its standard output streamed to consume
+// instead of the terminal. Prompts still reach the terminal (see promptOut).
+func captureOutput(parts []string, consume func(r io.Reader)) error {
+	return capture(func() { handleCommand(parts[0], parts) }, consume)
+}
+
+// capture calls run with os.Stdout streamed to consume. Captures can nest
+// ("gxcat a | gxhash > sums"); captureStdout always keeps the real terminal.
+func capture(run func(), consume func(r io.Reader)) error {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		consume(r)
+		io.Copy(io.Discard, r) // never block the command if consume stops early
+		close(done)
+	}()
+
+	saved := os.Stdout
+	outermost := captureStdout == nil
+	if outermost {
+		captureStdout = saved
+	}
+	os.Stdout = w
+	func() {
+		defer func() {
+			w.Close()
+			os.Stdout = saved
+			if outermost {
+				captureStdout = nil
+			}
+		}()
+		run()
+	}()
+	<-done
+	r.Close()
+	return nil
+}
+
+// ==================== REDIRECTION ====================
+
+// redirectPath is the absolute path of the file output is being redirected
+// to, so commands that walk directories can leave it out
+var redirectPath string
+
+// splitRedirect separates "command ... > file" (or ">> file" to append) into
+// the command and the target; target is "" when there is no redirection
+func splitRedirect(parts []string) ([]string, string, bool, error) {
+	for i, part := range parts {
+		if part != ">" && part != ">>" {
+			continue
+		}
+		if i == 0 {
+			return nil, "", false, fmt.Errorf("missing command before '%s'", part)
+		}
+		if len(parts) != i+2 {
+			return nil, "", false, fmt.Errorf("'%s' must be followed by exactly one file name", part)
+		}
+		return parts[:i], parts[i+1], part == ">>", nil
+	}
+	return parts, "", false, nil
+}
+
+// isRedirectTarget reports whether path is the file output is being
+// redirected to, or the temporary file it is written through
+func isRedirectTarget(path string) bool {
+	if redirectPath == "" {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	return abs == redirectPath ||
+		(filepath.Dir(abs) == filepath.Dir(redirectPath) &&
+			strings.HasPrefix(filepath.Base(abs), "."+filepath.Base(redirectPath)+".gxtmp-"))
+}
+
+// redirectOutput runs a command with its output written to target. The file
+// is replaced atomically and the change can be undone with gxundo.
+func redirectOutput(command, target string, appendTo bool, run func()) (int64, error) {
+	if !validatePath(target) {
+		return 0, errInvalidInput
+	}
+	if info, err := os.Stat(target); err == nil && info.IsDir() {
+		fmt.Printf("Error: '%s' is a directory\n", target)
+		return 0, errInvalidInput
+	}
+	if isDryRun() {
+		// Show the output so the dry run can be checked
+		run()
+		dryRunf("would write the output of %s to '%s'", command, target)
+		return 0, nil
+	}
+
+	redirectPath, _ = filepath.Abs(target)
+	defer func() { redirectPath = "" }()
+
+	before := journalCapture(target)
+	var written int64
+	err := atomicWrite(target, 0644, func(w io.Writer) error {
+		if appendTo {
+			existing, err := os.Open(target)
+			if err == nil {
+				_, err = io.Copy(w, existing)
+				existing.Close()
+				if err != nil {
+					return err
+				}
+			} else if !os.IsNotExist(err) {
+				return err
+			}
+		}
+		var copyErr error
+		if err := capture(run, func(r io.Reader) { written, copyErr = io.Copy(w, r) }); err != nil {
+			return err
+		}
+		return copyErr
+	})
+	if err != nil {
+		fmt.Printf("Error writing to file '%s': %v\n", target, err)
+		return 0, err
+	}
+
+	kind := journalModify
+	if !before.State.Exists {
+		kind = journalCreate
+	}
+	recordJournal(kind, command, target, "", before)
+	return written, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureRun returns what run prints to standard output
+func captureRun(t *testing.T, run func()) string {
+	t.Helper()
+	var out strings.Builder
+	if err := capture(run, func(r io.Reader) { io.Copy(&out, r) }); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestHashPipe(t *testing.T) {
+	t.Setenv("GX_CONFIG_DIR", t.TempDir())
+	t.Chdir(t.TempDir())
+	files := map[string]string{
+		"a.txt":     "hello\n",
+		"no-eol.go": "package main", // no banner, no newline added
+		"utf16.txt": "\xff\xfeh\x00i\x00",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(name, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		sink []string
+		want string
+	}{
+		{"a.txt", []string{"gxhash"}, "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  -\n"},
+		{"a.txt", []string{"gxhash", "-a", "md5"}, "b1946ac92492d2347c6235b4d2611184  -\n"},
+		{"no-eol.go", []string{"gxhash", "-a", "sha1"}, "04eb6f1bdaf51ae48ed5cf0153fad8593467b778  -\n"},
+		{"utf16.txt", []string{"gxhash", "-a", "crc32"}, "e8afeb51  -\n"},
+	}
+	for _, tt := range tests {
+		got := captureRun(t, func() { runPipe([]string{"gxcat", tt.name}, tt.sink) })
+		if got != tt.want {
+			t.Errorf("gxcat %s | %s = %q, want %q", tt.name, strings.Join(tt.sink, " "), got, tt.want)
+		}
+	}
+}
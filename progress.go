@@ -0,0 +1,309 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==================== PROGRESS ====================
+
+// Long-running commands report progress through a shared progress value.
+// Progress always goes to standard error, never into command output: as a
+// single line that updates in place when standard error is a terminal, or
+// as JSON events, depending on the progress mode (gxset progress /
+// GX_PROGRESS).
+
+// Progress modes
+const (
+	progressAuto = "auto" // a live line when standard output and error are terminals
+	progressOn   = "on"   // a live line whenever standard error is a terminal
+	progressOff  = "off"  // never report progress
+	progressJSON = "json" // emit JSON events on standard error
+)
+
+const (
+	progressDelay    = 300 * time.Millisecond // do not draw for quick operations
+	progressInterval = 100 * time.Millisecond // redraw at most this often
+	progressJSONTick = time.Second            // JSON events at most this often
+)
+
+// progressMode selects how progress is reported; activeProgress is the
+// operation currently drawing a live line, if any
+var (
+	progressMode   = initialProgressMode()
+	activeProgress *progress
+)
+
+// initialProgressMode reads GX_PROGRESS, defaulting to auto
+func initialProgressMode() string {
+	switch mode := os.Getenv("GX_PROGRESS"); mode {
+	case progressOn, progressOff, progressJSON:
+		return mode
+	}
+	return progressAuto
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// progress tracks bytes and files processed by one operation
+type progress struct {
+	mu         sync.Mutex
+	label      string
+	style      string
+	totalBytes int64
+	totalFiles int64
+	doneBytes  int64
+	doneFiles  int64
+	start      time.Time
+	lastDraw   time.Time
+	drawn      bool
+}
+
+// progressEvent is the JSON form of a progress update
+type progressEvent struct {
+	Event      string  `json:"event"`
+	Label      string  `json:"label"`
+	Bytes      int64   `json:"bytes"`
+	TotalBytes int64   `json:"total_bytes,omitempty"`
+	Files      int64   `json:"files"`
+	TotalFiles int64   `json:"total_files,omitempty"`
+	Rate       float64 `json:"bytes_per_second"`
+	ETASeconds float64 `json:"eta_seconds,omitempty"`
+	Elapsed    float64 `json:"elapsed_seconds"`
+}
+
+// newProgress starts tracking an operation. Totals of zero mean unknown.
+// It returns nil when progress is disabled; all methods accept a nil receiver.
+func newProgress(label string, totalBytes, totalFiles int64) *progress {
+	style := progressMode
+	switch {
+	case style == progressOff:
+		return nil
+	case style == progressAuto && !isTerminal(os.Stdout):
+		return nil
+	case style != progressJSON && !isTerminal(os.Stderr):
+		return nil
+	}
+	p := &progress{
+		label:      label,
+		style:      style,
+		totalBytes: totalBytes,
+		totalFiles: totalFiles,
+		start:      time.Now(),
+	}
+	activeProgress = p
+	return p
+}
+
+// addBytes records processed bytes
+func (p *progress) addBytes(n int64) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.doneBytes += n
+	p.update()
+	p.mu.Unlock()
+}
+
+// addFile records one processed file
+func (p *progress) addFile() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.doneFiles++
+	p.update()
+	p.mu.Unlock()
+}
+
+// printf prints a line of regular output without corrupting the progress line
+func (p *progress) printf(format string, args ...interface{}) {
+	if p == nil {
+		fmt.Printf(format, args...)
+		return
+	}
+	p.mu.Lock()
+	p.clearLine()
+	fmt.Printf(format, args...)
+	p.mu.Unlock()
+}
+
+// finish removes the progress line (or emits the final JSON event)
+func (p *progress) finish() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if activeProgress == p {
+		activeProgress = nil
+	}
+	if p.style == progressJSON {
+		p.emit("done")
+		return
+	}
+	p.clearLine()
+}
+
+// pause erases the live line so a prompt or message can be printed;
+// it is redrawn on the next update
+func (p *progress) pause() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.clearLine()
+	p.mu.Unlock()
+}
+
+// reader wraps r so that bytes read through it are counted
+func (p *progress) reader(r io.Reader) io.Reader {
+	if p == nil {
+		return r
+	}
+	return &progressReader{r: r, p: p}
+}
+
+// progressReader counts bytes flowing through io.Copy
+type progressReader struct {
+	r io.Reader
+	p *progress
+}
+
+func (pr *progressReader) Read(buf []byte) (int, error) {
+	n, err := pr.r.Read(buf)
+	if n > 0 {
+		pr.p.addBytes(int64(n))
+	}
+	return n, err
+}
+
+// update redraws the progress if enough time has passed (caller holds mu)
+func (p *progress) update() {
+	now := time.Now()
+	if now.Sub(p.start) < progressDelay {
+		return
+	}
+	if p.style == progressJSON {
+		if now.Sub(p.lastDraw) >= progressJSONTick {
+			p.lastDraw = now
+			p.emit("progress")
+		}
+		return
+	}
+	if now.Sub(p.lastDraw) < progressInterval {
+		return
+	}
+	p.lastDraw = now
+	p.drawn = true
+	fmt.Fprintf(os.Stderr, "\r\033[K⏳ %s", p.status())
+}
+
+// clearLine erases the live progress line (caller holds mu)
+func (p *progress) clearLine() {
+	if p.drawn {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+		p.drawn = false
+		p.lastDraw = time.Time{}
+	}
+}
+
+// rate returns the throughput in bytes per second
+func (p *progress) rate() float64 {
+	elapsed := time.Since(p.start).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(p.doneBytes) / elapsed
+}
+
+// eta estimates the remaining time, or zero when unknown
+func (p *progress) eta() time.Duration {
+	elapsed := time.Since(p.start)
+	switch {
+	case p.totalBytes > 0 && p.doneBytes > 0:
+		remaining := float64(p.totalBytes-p.doneBytes) / float64(p.doneBytes)
+		return time.Duration(remaining * float64(elapsed))
+	case p.totalFiles > 0 && p.doneFiles > 0:
+		remaining := float64(p.totalFiles-p.doneFiles) / float64(p.doneFiles)
+		return time.Duration(remaining * float64(elapsed))
+	}
+	return 0
+}
+
+// status formats the one-line progress summary
+func (p *progress) status() string {
+	var parts []string
+	if p.totalBytes > 0 {
+		pct := float64(p.doneBytes) * 100 / float64(p.totalBytes)
+		parts = append(parts, fmt.Sprintf("%s / %s (%.0f%%)", formatBytes(p.doneBytes), formatBytes(p.totalBytes), pct))
+	} else if p.doneBytes > 0 {
+		parts = append(parts, formatBytes(p.doneBytes))
+	}
+	if p.totalFiles > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d files", p.doneFiles, p.totalFiles))
+	} else if p.doneFiles > 0 {
+		parts = append(parts, fmt.Sprintf("%d files", p.doneFiles))
+	}
+	if p.doneBytes > 0 {
+		parts = append(parts, formatBytes(int64(p.rate()))+"/s")
+	}
+	if eta := p.eta(); eta > 0 {
+		parts = append(parts, "ETA "+formatDuration(eta))
+	}
+	return p.label + ": " + strings.Join(parts, " · ")
+}
+
+// emit writes a JSON progress event (caller holds mu)
+func (p *progress) emit(event string) {
+	data, err := json.Marshal(progressEvent{
+		Event:      event,
+		Label:      p.label,
+		Bytes:      p.doneBytes,
+		TotalBytes: p.totalBytes,
+		Files:      p.doneFiles,
+		TotalFiles: p.totalFiles,
+		Rate:       p.rate(),
+		ETASeconds: p.eta().Seconds(),
+		Elapsed:    time.Since(p.start).Seconds(),
+	})
+	if err == nil {
+		fmt.Fprintln(os.Stderr, string(data))
+	}
+}
+
+// formatBytes renders a byte count with a binary unit (B, KB, MB, GB, TB)
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n)
+	units := []string{"KB", "MB", "GB", "TB"}
+	i := -1
+	for value >= unit && i < len(units)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", value, units[i])
+}
+
+// formatDuration renders a duration as m:ss or h:mm:ss
+func formatDuration(d time.Duration) string {
+	total := int(d.Round(time.Second).Seconds())
+	h, m, s := total/3600, (total/60)%60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ==================== REPLACE ====================
+
+// gxreplace works line by line: each file is streamed once to count matches
+// and build the preview, then streamed again into an atomic temporary file.
+// Memory use therefore does not grow with file size, but a pattern cannot
+// match across a line break.
+
+const replacePreviewLines = 10 // changed lines shown per file in the preview
+
+// replaceOptions holds the parsed gxreplace arguments
+type replaceOptions struct {
+	old       string
+	new       string
+	regex     bool
+	ignore    bool
+	wholeWord bool
+	patterns  []string
+}
+
+// replaceResult summarizes the changes planned for one file
+type replaceResult struct {
+	path    string
+	matches int
+	lines   int
+	preview []string
+}
+
+// parseReplaceArgs parses "gxreplace [-e] [-i] [-w] old new file|glob..."
+func parseReplaceArgs(args []string) (replaceOptions, error) {
+	var opts replaceOptions
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i+1:]...)
+			break
+		}
+		switch arg {
+		case "-e", "--regex":
+			opts.regex = true
+		case "-i", "--ignore-case":
+			opts.ignore = true
+		case "-w", "--word":
+			opts.wholeWord = true
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	if len(rest) < 3 {
+		return opts, fmt.Errorf("missing arguments")
+	}
+	opts.old, opts.new, opts.patterns = rest[0], rest[1], rest[2:]
+	return opts, nil
+}
+
+// compile builds the matcher for the options
+func (o replaceOptions) compile() (*regexp.Regexp, error) {
+	pattern := o.old
+	if !o.regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if o.wholeWord {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	if o.ignore {
+		pattern = `(?i)` + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// replaceLine applies the replacement to one line
+func (o replaceOptions) replaceLine(re *regexp.Regexp, line string) string {
+	if o.regex {
+		return re.ReplaceAllString(line, o.new)
+	}
+	return re.ReplaceAllLiteralString(line, o.new)
+}
+
+// expandFileArgs expands glob patterns into the list of regular files they match
+func expandFileArgs(patterns []string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		if !validatePath(pattern) {
+			return nil, errInvalidInput
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %v", pattern, err)
+		}
+		if matches == nil {
+			matches = []string{pattern}
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			if info.IsDir() || seen[match] {
+				continue
+			}
+			seen[match] = true
+			files = append(files, match)
+		}
+	}
+	return files, nil
+}
+
+// forEachLine streams a file line by line, passing each line without its
+// terminator together with the terminator ("\n" or "" for the last line)
+func forEachLine(r io.Reader, fn func(line, eol string) error) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			eol := ""
+			if strings.HasSuffix(line, "\n") {
+				line, eol = line[:len(line)-1], "\n"
+			}
+			if ferr := fn(line, eol); ferr != nil {
+				return ferr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// planReplace scans a file and records how many replacements would be made
+func planReplace(path string, opts replaceOptions, re *regexp.Regexp) (replaceResult, error) {
+	result := replaceResult{path: path}
+	file, err := os.Open(path)
+	if err != nil {
+		return result, err
+	}
+	defer file.Close()
+
+	lineNum := 0
+	err = forEachLine(file, func(line, _ string) error {
+		lineNum++
+		matches := re.FindAllStringIndex(line, -1)
+		if len(matches) == 0 {
+			return nil
+		}
+		result.matches += len(matches)
+		result.lines++
+		if len(result.preview) < replacePreviewLines*3 {
+			result.preview = append(result.preview,
+				fmt.Sprintf("@@ line %d @@", lineNum),
+				"- "+line,
+				"+ "+opts.replaceLine(re, line))
+		}
+		return nil
+	})
+	return result, err
+}
+
+// printReplacePreview shows a diff-style preview of the planned changes
+func printReplacePreview(result replaceResult) {
+	fmt.Printf("--- a/%s\n+++ b/%s\n", result.path, result.path)
+	for _, line := range result.preview {
+		fmt.Println(line)
+	}
+	if shown := len(result.preview) / 3; result.lines > shown {
+		fmt.Printf("... and %d more changed line(s)\n", result.lines-shown)
+	}
+}
+
+// applyReplace streams the file through the replacement into an atomic write
+func applyReplace(path string, opts replaceOptions, re *regexp.Regexp) (int64, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	var written int64
+	err = atomicWrite(path, 0644, func(w io.Writer) error {
+		out := bufio.NewWriter(w)
+		err := forEachLine(in, func(line, eol string) error {
+			n, err := out.WriteString(opts.replaceLine(re, line) + eol)
+			written += int64(n)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		return out.Flush()
+	})
+	return written, err
+}
+
+// gxreplace replaces matches of a literal string or regular expression in one
+// or more files, showing a preview and asking for confirmation first
+func gxreplace(opts replaceOptions, files []string) (int64, error) {
+	re, err := opts.compile()
+	if err != nil {
+		fmt.Printf("❌ Error: Invalid pattern '%s': %v\n", opts.old, err)
+		return 0, errInvalidInput
+	}
+
+	var plans []replaceResult
+	total := 0
+	for _, path := range files {
+		result, err := planReplace(path, opts, re)
+		if err != nil {
+			fmt.Printf("Error reading file '%s': %v\n", path, err)
+			return 0, err
+		}
+		if result.matches > 0 {
+			plans = append(plans, result)
+			total += result.matches
+		}
+	}
+
+	if total == 0 {
+		fmt.Printf("No matches for '%s' in %d file(s) — nothing changed\n", opts.old, len(files))
+		return 0, nil
+	}
+
+	if isDryRun() || !skipConfirm() {
+		for _, plan := range plans {
+			printReplacePreview(plan)
+		}
+	}
+
+	if isDryRun() {
+		dryRunf("would make %d replacement(s) in %d file(s)", total, len(plans))
+		return 0, nil
+	}
+
+	if !confirmAction("Apply %d replacement(s) in %d file(s)?", total, len(plans)) {
+		return 0, errCancelled
+	}
+
+	var written int64
+	for _, plan := range plans {
+		before := journalCapture(plan.path)
+		n, err := applyReplace(plan.path, opts, re)
+		if err != nil {
+			fmt.Printf("Error writing file '%s': %v\n", plan.path, err)
+			return written, err
+		}
+		recordJournal(journalModify, "gxreplace", plan.path, "", before)
+		written += n
+		fmt.Printf("✅ %s: %d replacement(s) on %d line(s)\n", plan.path, plan.matches, plan.lines)
+	}
+
+	fmt.Printf("--- Replaced %d occurrence(s) of '%s' with '%s' in %d file(s) ---\n", total, opts.old, opts.new, len(plans))
+	return written, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -11,9 +12,14 @@ const (
 	MAX_PATH_LENGTH     = 260               // Windows MAX_PATH
 	MAX_FILE_SIZE       = 512 * 1024 * 1024 // 512MB limit
 	MAX_FILENAME_LENGTH = 255
+	MAX_ARGS            = 32 // words per command line, including the command
 	ALLOWED_NAME_CHARS  = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789._-@+= ()"
 )
 
+// errInvalidInput is returned by handlers when input validation fails.
+// The validation helpers have already printed the reason.
+var errInvalidInput = errors.New("invalid input")
+
 // isPathTraversal checks if a path tries to escape the current working directory
 func isPathTraversal(path string) bool {
 	// Reject absolute paths
@@ -63,6 +69,19 @@ func validatePath(path string) bool {
 	return true
 }
 
+// findExecRunning is set while gxfind -exec runs commands on its matches,
+// which are paths below the search roots rather than bare file names
+var findExecRunning bool
+
+// validateTarget checks the existing file a command operates on: a file name
+// in the working directory, or a path below it when gxfind -exec passes a match
+func validateTarget(name string) bool {
+	if findExecRunning {
+		return validatePath(name)
+	}
+	return validateFilename(name)
+}
+
 // validateFilename checks if a filename is safe
 func validateFilename(filename string) bool {
 	if len(strings.TrimSpace(filename)) == 0 {
@@ -198,7 +217,7 @@ func ValidateCommandInput(command string, args []string) bool {
 	}
 
 	// Validate arguments count
-	if len(args) > 10 {
+	if len(args) > MAX_ARGS {
 		fmt.Println("❌ Error: Too many arguments")
 		return false
 	}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// ==================== TAIL ====================
+
+// tailChunk is how much gxtail reads at a time while scanning backwards
+const tailChunk = 64 * 1024
+
+// followInterval is how often gxtail -f checks files for new data
+const followInterval = 250 * time.Millisecond
+
+// tailLineOffset scans backwards from the end of a file and returns the
+// offset where its last n lines begin. A final newline does not start an
+// extra empty line. Only one chunk is held in memory, so line length and
+// file size do not matter.
+func tailLineOffset(r io.ReaderAt, size int64, n int) (int64, error) {
+	if n == 0 {
+		return size, nil
+	}
+	buf := make([]byte, tailChunk)
+	end := size
+	newlines := 0
+	for end > 0 {
+		start := max(end-tailChunk, 0)
+		chunk := buf[:end-start]
+		if _, err := r.ReadAt(chunk, start); err != nil && err != io.EOF {
+			return 0, err
+		}
+		for i := len(chunk) - 1; i >= 0; i-- {
+			if chunk[i] != '\n' || start+int64(i) == size-1 {
+				continue
+			}
+			newlines++
+			if newlines == n {
+				return start + int64(i) + 1, nil
+			}
+		}
+		end = start
+	}
+	return 0, nil
+}
+
+// tailStream keeps the last n lines of a non-seekable stream such as a pipe
+func tailStream(r io.Reader, n int, w io.Writer) (int, error) {
+	if n == 0 {
+		_, err := io.Copy(io.Discard, r)
+		return 0, err
+	}
+	ring := make([][]byte, 0, n)
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if len(ring) == n {
+				ring = append(ring[:0], ring[1:]...)
+			}
+			ring = append(ring, line)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	for _, line := range ring {
+		if _, err := w.Write(line); err != nil {
+			return 0, err
+		}
+	}
+	return len(ring), nil
+}
+
+// endsWithNewline reports whether the file's last byte is a newline
+func endsWithNewline(r io.ReaderAt, size int64) bool {
+	if size == 0 {
+		return true
+	}
+	last := make([]byte, 1)
+	_, err := r.ReadAt(last, size-1)
+	return err == nil && last[0] == '\n'
+}
+
+// tailFile displays the last lines (or bytes) of a file by seeking backwards
+// from the end instead of reading the whole file
+func tailFile(filename string, opts viewOptions) {
+	file, err := os.Open(filename)
+	if err != nil {
+		fmt.Printf("Error opening file '%s': %v\n", filename, err)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		fmt.Printf("Error reading file: %v\n", err)
+		return
+	}
+	if info.IsDir() {
+		fmt.Printf("Error: '%s' is a directory\n", filename)
+		return
+	}
+	showTail(file, info, filename, opts)
+}
+
+// showTail prints the tail of an open file up to the size in info, so gxtail
+// -f can carry on from exactly there with the same handle
+func showTail(file *os.File, info os.FileInfo, filename string, opts viewOptions) {
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	if !info.Mode().IsRegular() {
+		fmt.Fprintf(out, "\n--- Last %d lines of %s ---\n", opts.lines, filename)
+		var last bytes.Buffer
+		shown, err := tailStream(file, opts.lines, &last)
+		if err == nil {
+			_, err = copyView(out, &last, opts.showAll)
+		}
+		if err != nil {
+			fmt.Fprintf(out, "Error reading file: %v\n", err)
+			return
+		}
+		fmt.Fprintf(out, "--- End of tail (showed %d lines) ---\n", shown)
+		return
+	}
+
+	size := info.Size()
+	if size == 0 {
+		fmt.Fprintf(out, "\n--- Last %d lines of %s ---\n", opts.lines, filename)
+		fmt.Fprintln(out, "(file is empty)")
+		return
+	}
+	if enc, _ := sniffText(file); enc != encUTF8 {
+		tailDecoded(out, file, filename, size, enc, opts)
+		return
+	}
+
+	var offset int64
+	if opts.bytes >= 0 {
+		offset = max(size-opts.bytes, 0)
+		fmt.Fprintf(out, "\n--- Last %d bytes of %s ---\n", size-offset, filename)
+	} else {
+		var err error
+		offset, err = tailLineOffset(file, size, opts.lines)
+		if err != nil {
+			fmt.Fprintf(out, "Error reading file: %v\n", err)
+			return
+		}
+		fmt.Fprintf(out, "\n--- Last %d lines of %s ---\n", opts.lines, filename)
+	}
+
+	tracker, err := copyView(out, io.NewSectionReader(file, offset, size-offset), opts.showAll)
+	if err != nil {
+		fmt.Fprintf(out, "Error reading file: %v\n", err)
+		return
+	}
+	if tracker.written > 0 && !tracker.endsWithNewline {
+		fmt.Fprintln(out)
+	}
+
+	if opts.bytes >= 0 {
+		fmt.Fprintf(out, "--- End of tail (%d bytes) ---\n", size-offset)
+		return
+	}
+	shown := tracker.lines
+	if tracker.written > 0 && !endsWithNewline(file, size) {
+		shown++
+	}
+	if offset == 0 {
+		fmt.Fprintf(out, "--- End of tail (whole file, %d lines) ---\n", shown)
+	} else {
+		fmt.Fprintf(out, "--- End of tail (showed %d lines) ---\n", shown)
+	}
+}
+
+// tailDecoded is tailFile for text in another encoding. Line ends cannot be
+// found by scanning UTF-16 backwards byte by byte, so the whole file is
+// streamed through the decoder, keeping only the last lines in memory.
+func tailDecoded(out io.Writer, file *os.File, filename string, size int64, enc textEncoding, opts viewOptions) {
+	title := fmt.Sprintf("%s [%s]", filename, enc)
+	var last bytes.Buffer
+	var err error
+	var offset int64
+	shown := 0
+	if opts.bytes >= 0 {
+		offset = max(size-opts.bytes, 0)
+		if enc.isUTF16() {
+			offset += offset & 1 // start on a whole code unit
+		}
+		fmt.Fprintf(out, "\n--- Last %d bytes of %s ---\n", size-offset, title)
+		_, err = io.Copy(&last, newDecodeReader(io.NewSectionReader(file, offset, size-offset), enc))
+	} else {
+		fmt.Fprintf(out, "\n--- Last %d lines of %s ---\n", opts.lines, title)
+		shown, err = tailStream(newDecodeReader(io.NewSectionReader(file, 0, size), enc), opts.lines, &last)
+	}
+	var tracker *newlineTracker
+	if err == nil {
+		tracker, err = copyView(out, &last, opts.showAll)
+	}
+	if err != nil {
+		fmt.Fprintf(out, "Error reading file: %v\n", err)
+		return
+	}
+	if tracker.written > 0 && !tracker.endsWithNewline {
+		fmt.Fprintln(out)
+	}
+	if opts.bytes >= 0 {
+		fmt.Fprintf(out, "--- End of tail (%d bytes) ---\n", size-offset)
+	} else {
+		fmt.Fprintf(out, "--- End of tail (showed %d lines) ---\n", shown)
+	}
+}
+
+// decodeChunk converts data newly read from a file in enc to UTF-8 and
+// returns the trailing bytes that do not form a whole character yet
+func decodeChunk(data []byte, enc textEncoding) (text, rest []byte) {
+	n := len(data)
+	if enc.isUTF16() {
+		n &^= 1
+		// Hold back a high surrogate until its low half arrives
+		if n >= 2 {
+			unit := uint16(data[n-2]) | uint16(data[n-1])<<8
+			if enc == encUTF16BE {
+				unit = uint16(data[n-2])<<8 | uint16(data[n-1])
+			}
+			if unit >= 0xD800 && unit < 0xDC00 {
+				n -= 2
+			}
+		}
+	}
+	text, _ = io.ReadAll(newDecodeReader(bytes.NewReader(data[:n]), enc))
+	return text, append([]byte(nil), data[n:]...)
+}
+
+// newlineTracker passes writes through while counting newlines and
+// remembering whether the output so far ends with one
+type newlineTracker struct {
+	w               io.Writer
+	written         int64
+	lines           int
+	endsWithNewline bool
+}
+
+func (t *newlineTracker) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		t.written += int64(len(p))
+		t.lines += bytes.Count(p, []byte{'\n'})
+		t.endsWithNewline = p[len(p)-1] == '\n'
+	}
+	return t.w.Write(p)
+}
+
+// followedFile tracks one file watched by gxtail -f
+type followedFile struct {
+	name    string
+	file    *os.File
+	info    os.FileInfo // identity of the open file, to detect rotation
+	offset  int64
+	enc     textEncoding // appended data is decoded from this encoding
+	raw     []byte       // trailing bytes not yet decoded
+	partial []byte       // trailing data not yet terminated by a newline
+	missing bool
+}
+
+// followOutput prints data from followed files, with a "==> name <=="
+// header whenever the source changes and more than one file is followed
+type followOutput struct {
+	multi   bool
+	showAll bool
+	last    string
+}
+
+func (o *followOutput) write(name string, data []byte) {
+	if o.multi && o.last != name {
+		fmt.Printf("\n==> %s <==\n", name)
+		o.last = name
+	}
+	copyView(os.Stdout, bytes.NewReader(data), o.showAll)
+}
+
+func (o *followOutput) notice(name, message string) {
+	fmt.Printf("⚠️  %s: %s\n", name, message)
+	o.last = ""
+}
+
+// open (re)opens the file by name
+func (f *followedFile) open() error {
+	file, err := os.Open(f.name)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.enc, _ = sniffText(file)
+	f.file, f.info, f.offset, f.raw, f.partial = file, info, 0, nil, nil
+	return nil
+}
+
+// drain prints complete lines appended since the last read. Partial lines
+// are held back so output from several files never interleaves mid-line.
+func (f *followedFile) drain(out *followOutput) {
+	if f.file == nil {
+		return
+	}
+	buf := make([]byte, tailChunk)
+	for {
+		n, err := f.file.ReadAt(buf, f.offset)
+		if n > 0 {
+			f.offset += int64(n)
+			data := buf[:n]
+			if f.enc != encUTF8 {
+				data, f.raw = decodeChunk(append(f.raw, data...), f.enc)
+			}
+			f.partial = append(f.partial, data...)
+			if cut := bytes.LastIndexByte(f.partial, '\n'); cut >= 0 {
+				out.write(f.name, f.partial[:cut+1])
+				f.partial = append(f.partial[:0], f.partial[cut+1:]...)
+			}
+		}
+		if n == 0 || err != nil {
+			return
+		}
+	}
+}
+
+// flush prints any held-back partial line
+func (f *followedFile) flush(out *followOutput) {
+	if len(f.partial) > 0 {
+		out.write(f.name, append(f.partial, '\n'))
+		f.partial = nil
+	}
+}
+
+// poll checks the file for rotation, truncation and new data
+func (f *followedFile) poll(out *followOutput) {
+	info, err := os.Stat(f.name)
+	switch {
+	case err != nil:
+		// Rotated away and not recreated yet: finish the old file and wait
+		f.drain(out)
+		if !f.missing {
+			out.notice(f.name, "file removed; waiting for it to reappear")
+			f.missing = true
+		}
+		return
+	case f.file == nil || !os.SameFile(f.info, info):
+		if f.file != nil {
+			f.drain(out)
+			f.flush(out)
+			f.file.Close()
+			f.file = nil
+			out.notice(f.name, "file replaced (rotated); following the new file")
+		} else {
+			out.notice(f.name, "file appeared; following it")
+		}
+		if err := f.open(); err != nil {
+			return
+		}
+		f.missing = false
+	case info.Size() < f.offset:
+		out.notice(f.name, "file truncated; reading from the start")
+		f.offset, f.raw, f.partial = 0, nil, nil
+	}
+	f.drain(out)
+}
+
+// followFiles prints the tail of each file and then streams appended data
+// until Ctrl+C, which stops following without exiting the shell
+func followFiles(names []string, opts viewOptions) {
+	files := make([]*followedFile, 0, len(names))
+	for _, name := range names {
+		f := &followedFile{name: name}
+		if err := f.open(); err != nil {
+			fmt.Printf("Error opening file '%s': %v\n", name, err)
+			f.missing = true
+		} else if f.info.IsDir() {
+			fmt.Printf("Error: '%s' is a directory\n", name)
+			f.file.Close()
+			continue
+		} else {
+			// Data appended after the Stat in open is read by poll
+			showTail(f.file, f.info, name, opts)
+			f.offset = f.info.Size()
+		}
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	fmt.Printf("\n--- Following %d file(s) (Ctrl+C to stop) ---\n", len(files))
+	out := &followOutput{multi: len(files) > 1, showAll: opts.showAll}
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-interrupt:
+			for _, f := range files {
+				f.flush(out)
+				if f.file != nil {
+					f.file.Close()
+				}
+			}
+			fmt.Println("\n--- Stopped following ---")
+			return
+		case <-ticker.C:
+			for _, f := range files {
+				f.poll(out)
+			}
+		}
+	}
+}
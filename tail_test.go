@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestTailLineOffset(t *testing.T) {
+	long := strings.Repeat("x", tailChunk+10) // lines spanning chunk boundaries
+	tests := []struct {
+		name string
+		data string
+		n    int
+		want string
+	}{
+		{"trailing newline", "a\nb\nc\n", 2, "b\nc\n"},
+		{"no trailing newline", "a\nb\nc", 2, "b\nc"},
+		{"no trailing newline, one line", "a\nb\nc", 1, "c"},
+		{"more lines than the file", "a\nb", 5, "a\nb"},
+		{"single line without newline", "abc", 1, "abc"},
+		{"only a newline", "\n", 1, "\n"},
+		{"blank last line", "a\n\n", 1, "\n"},
+		{"zero lines", "a\nb\n", 0, ""},
+		{"across chunks", "a\n" + long + "\n" + long, 2, long + "\n" + long},
+		{"across chunks with newline", long + "\nb\n", 1, "b\n"},
+	}
+	for _, tt := range tests {
+		r := strings.NewReader(tt.data)
+		offset, err := tailLineOffset(r, int64(len(tt.data)), tt.n)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := tt.data[offset:]; got != tt.want {
+			t.Errorf("%s: tail %d = %.20q (offset %d), want %.20q", tt.name, tt.n, got, offset, tt.want)
+		}
+	}
+}
+
+func TestTailStream(t *testing.T) {
+	tests := []struct {
+		data  string
+		n     int
+		want  string
+		shown int
+	}{
+		{"a\nb\nc\n", 2, "b\nc\n", 2},
+		{"a\nb\nc", 2, "b\nc", 2},
+		{"a\nb", 5, "a\nb", 2},
+		{"", 3, "", 0},
+		{"a\nb\n", 0, "", 0},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		shown, err := tailStream(strings.NewReader(tt.data), tt.n, &out)
+		if err != nil || out.String() != tt.want || shown != tt.shown {
+			t.Errorf("tailStream(%q, %d) = %q, %d, %v; want %q, %d", tt.data, tt.n, out.String(), shown, err, tt.want, tt.shown)
+		}
+	}
+}
+
+func TestEndsWithNewline(t *testing.T) {
+	for data, want := range map[string]bool{"": true, "a\n": true, "a": false, "a\nb": false} {
+		if got := endsWithNewline(strings.NewReader(data), int64(len(data))); got != want {
+			t.Errorf("endsWithNewline(%q) = %v, want %v", data, got, want)
+		}
+	}
+}
+
+// utf16le encodes s as UTF-16LE without a byte order mark
+func utf16le(s string) []byte {
+	var b []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = append(b, byte(u), byte(u>>8))
+	}
+	return b
+}
+
+func TestDecodeChunk(t *testing.T) {
+	emoji := utf16le("😀")
+	tests := []struct {
+		name string
+		data []byte
+		enc  textEncoding
+		text string
+		rest int
+	}{
+		{"whole units", utf16le("ab\n"), encUTF16LE, "ab\n", 0},
+		{"odd byte held back", append(utf16le("ab"), 'c'), encUTF16LE, "ab", 1},
+		{"high surrogate held back", append(utf16le("a"), emoji[:2]...), encUTF16LE, "a", 2},
+		{"surrogate pair", append(utf16le("a"), emoji...), encUTF16LE, "a😀", 0},
+		{"big endian", []byte{0, 'h', 0, 'i', 0}, encUTF16BE, "hi", 1},
+		{"windows-1252", []byte{0x80, 'x'}, encCP1252, "€x", 0},
+	}
+	for _, tt := range tests {
+		text, rest := decodeChunk(tt.data, tt.enc)
+		if string(text) != tt.text || len(rest) != tt.rest {
+			t.Errorf("%s: decodeChunk = %q, %d byte(s) left; want %q, %d", tt.name, text, len(rest), tt.text, tt.rest)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"sort"
+)
+
+// ==================== COLOR THEMES ====================
+
+// colorReset ends any color started by a theme
+const colorReset = "\033[0m"
+
+// tokenKind names what a piece of colored output is, so that every command
+// takes its colors from the same theme
+type tokenKind uint8
+
+const (
+	tokText     tokenKind = iota
+	tokKeyword            // language keywords, list markers
+	tokType               // built-in types and functions, shell builtins
+	tokString             // string literals, link targets
+	tokNumber             // numeric literals
+	tokComment            // comments, block quotes
+	tokKey                // JSON/YAML keys, link text
+	tokVariable           // shell variables, YAML anchors
+	tokHeading            // Markdown headings
+	tokCode               // Markdown code spans and fenced blocks
+	tokMatch              // search matches (gxgrep, gxfzf, gxhex -d)
+	tokFile               // file names in multi-file output
+)
+
+// theme maps token kinds to ANSI escape sequences; kinds that are missing
+// are printed without color
+type theme map[tokenKind]string
+
+var themes = map[string]theme{
+	"default": {
+		tokKeyword:  "\033[1;35m",
+		tokType:     "\033[36m",
+		tokString:   "\033[32m",
+		tokNumber:   "\033[33m",
+		tokComment:  "\033[90m",
+		tokKey:      "\033[34m",
+		tokVariable: "\033[36m",
+		tokHeading:  "\033[1;34m",
+		tokCode:     "\033[33m",
+		tokMatch:    "\033[1;31m",
+		tokFile:     "\033[35m",
+	},
+	"light": {
+		tokKeyword:  "\033[1;34m",
+		tokType:     "\033[35m",
+		tokString:   "\033[31m",
+		tokNumber:   "\033[34m",
+		tokComment:  "\033[2;32m",
+		tokKey:      "\033[36m",
+		tokVariable: "\033[35m",
+		tokHeading:  "\033[1;31m",
+		tokCode:     "\033[32m",
+		tokMatch:    "\033[1;31;47m",
+		tokFile:     "\033[34m",
+	},
+	"mono": {
+		tokKeyword: "\033[1m",
+		tokComment: "\033[2m",
+		tokHeading: "\033[1;4m",
+		tokCode:    "\033[4m",
+		tokMatch:   "\033[7m",
+		tokFile:    "\033[1m",
+	},
+	"none": {},
+}
+
+// themeName is the active theme (gxset theme, GX_THEME)
+var themeName = initialTheme()
+
+func initialTheme() string {
+	if name := os.Getenv("GX_THEME"); themes[name] != nil {
+		return name
+	}
+	return "default"
+}
+
+// themeNames lists the available themes in alphabetical order
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// themeColor returns the escape sequence for kind in the active theme
+func themeColor(kind tokenKind) string {
+	return themes[themeName][kind]
+}
+
+// colorsEnabled reports whether colored output should be written: stdout
+// is a terminal and the theme is not "none"
+func colorsEnabled() bool {
+	return themeName != "none" && isTerminal(os.Stdout)
+}
+
+// paint wraps s in the active theme's color for kind
+func paint(kind tokenKind, s string) string {
+	color := themeColor(kind)
+	if color == "" || s == "" {
+		return s
+	}
+	return color + s + colorReset
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==================== TRASH ====================
+
+// The trash uses the freedesktop.org Trash layout: deleted items are moved to
+// <trash>/files/<id> and described by <trash>/info/<id>.trashinfo. On Linux
+// this is the home trash used by desktop file managers.
+//
+// Only the home trash is used. The specification puts items from other
+// filesystems in a per-volume $topdir/.Trash-$uid; gx instead copies them into
+// the home trash and removes the original, so trashing a file on another
+// volume costs a full copy and file managers may not list it under that
+// volume's trash.
+
+const trashInfoTimeLayout = "2006-01-02T15:04:05"
+
+// trashItem describes one entry in the trash
+type trashItem struct {
+	ID           string
+	OriginalPath string
+	DeletedAt    time.Time
+}
+
+// trashPath returns the trash root directory without creating it
+func trashPath() (string, error) {
+	if runtime.GOOS != "linux" {
+		dir, err := configDirPath()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "Trash"), nil
+	}
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("locating home directory: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "Trash"), nil
+}
+
+// trashDir returns the trash root directory, creating its layout if needed.
+// In dry-run mode nothing is created; callers treat a missing layout as an
+// empty trash.
+func trashDir() (string, error) {
+	dir, err := trashPath()
+	if err != nil {
+		return "", err
+	}
+	if isDryRun() {
+		return dir, nil
+	}
+	for _, sub := range []string{"files", "info"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0700); err != nil {
+			return "", fmt.Errorf("creating trash directory: %w", err)
+		}
+	}
+	return dir, nil
+}
+
+// moveToTrash moves a file or directory into the trash and returns its trash ID
+func moveToTrash(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Lstat(absPath); err != nil {
+		return "", err
+	}
+
+	dir, err := trashDir()
+	if err != nil {
+		return "", err
+	}
+
+	// Reserve a unique ID by creating the .trashinfo file exclusively
+	base := filepath.Base(absPath)
+	var id string
+	var infoFile *os.File
+	for n := 1; ; n++ {
+		id = base
+		if n > 1 {
+			id = fmt.Sprintf("%s.%d", base, n)
+		}
+		infoFile, err = os.OpenFile(filepath.Join(dir, "info", id+".trashinfo"), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return "", err
+		}
+	}
+
+	info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: filepath.ToSlash(absPath)}).EscapedPath(),
+		time.Now().Format(trashInfoTimeLayout))
+	_, werr := infoFile.WriteString(info)
+	cerr := infoFile.Close()
+	if werr != nil || cerr != nil {
+		os.Remove(infoFile.Name())
+		if werr != nil {
+			return "", werr
+		}
+		return "", cerr
+	}
+
+	if err := movePath(absPath, filepath.Join(dir, "files", id)); err != nil {
+		os.Remove(infoFile.Name())
+		return "", err
+	}
+	return id, nil
+}
+
+// readTrashInfo parses a .trashinfo file
+func readTrashInfo(path string) (trashItem, error) {
+	item := trashItem{ID: strings.TrimSuffix(filepath.Base(path), ".trashinfo")}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return item, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Path":
+			decoded, err := url.PathUnescape(value)
+			if err != nil {
+				return item, fmt.Errorf("invalid Path in %s: %v", path, err)
+			}
+			item.OriginalPath = filepath.FromSlash(decoded)
+		case "DeletionDate":
+			t, err := time.ParseInLocation(trashInfoTimeLayout, value, time.Local)
+			if err == nil {
+				item.DeletedAt = t
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return item, err
+	}
+	if item.OriginalPath == "" {
+		return item, fmt.Errorf("missing Path in %s", path)
+	}
+	return item, nil
+}
+
+// listTrash returns all trash entries, oldest first
+func listTrash() ([]trashItem, error) {
+	dir, err := trashDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "info"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var items []trashItem
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".trashinfo") {
+			continue
+		}
+		item, err := readTrashInfo(filepath.Join(dir, "info", entry.Name()))
+		if err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].DeletedAt.Equal(items[j].DeletedAt) {
+			return items[i].ID < items[j].ID
+		}
+		return items[i].DeletedAt.Before(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+// restoreFromTrash moves a trash entry back to its original location
+func restoreFromTrash(id string) (trashItem, error) {
+	dir, err := trashDir()
+	if err != nil {
+		return trashItem{}, err
+	}
+
+	if id != filepath.Base(id) || id == "." || id == ".." {
+		return trashItem{}, fmt.Errorf("invalid trash ID '%s'", id)
+	}
+
+	infoPath := filepath.Join(dir, "info", id+".trashinfo")
+	item, err := readTrashInfo(infoPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return item, fmt.Errorf("no trash entry with ID '%s'", id)
+		}
+		return item, err
+	}
+
+	if _, err := os.Lstat(item.OriginalPath); err == nil {
+		return item, fmt.Errorf("'%s' already exists", item.OriginalPath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(item.OriginalPath), 0755); err != nil {
+		return item, err
+	}
+	if err := movePath(filepath.Join(dir, "files", id), item.OriginalPath); err != nil {
+		return item, err
+	}
+	os.Remove(infoPath)
+	return item, nil
+}
+
+// purgeTrashItem permanently removes a trash entry and its metadata
+func purgeTrashItem(dir, id string) error {
+	if err := os.RemoveAll(filepath.Join(dir, "files", id)); err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(dir, "info", id+".trashinfo"))
+}
+
+// parseAge parses durations such as "7d", "12h", "30m" or "2w"
+func parseAge(s string) (time.Duration, error) {
+	if len(s) > 1 {
+		unit := s[len(s)-1]
+		if n, err := strconv.ParseFloat(s[:len(s)-1], 64); err == nil {
+			switch unit {
+			case 'd':
+				return time.Duration(n * float64(24*time.Hour)), nil
+			case 'w':
+				return time.Duration(n * float64(7*24*time.Hour)), nil
+			}
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age '%s' (use e.g. 7d, 12h, 30m)", s)
+	}
+	return d, nil
+}
+
+// gxtrash lists, restores or empties the trash
+func gxtrash(args []string) {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+
+	switch args[0] {
+	case "list":
+		items, err := listTrash()
+		if err != nil {
+			fmt.Printf("Error reading trash: %v\n", err)
+			return
+		}
+		if len(items) == 0 {
+			fmt.Println("🗑️  Trash is empty")
+			return
+		}
+		dir, _ := trashDir()
+		fmt.Println("ID                   Deleted              Size        Original path")
+		fmt.Println("--                   -------              ----        -------------")
+		for _, item := range items {
+			size, _ := dirSize(filepath.Join(dir, "files", item.ID))
+			fmt.Printf("%-20s %-20s %-10d  %s\n", item.ID, item.DeletedAt.Format("2006-01-02 15:04:05"), size, item.OriginalPath)
+		}
+		fmt.Printf("--- %d item(s) in trash ---\n", len(items))
+
+	case "restore":
+		if len(args) < 2 {
+			fmt.Println("Error: Missing trash ID")
+			fmt.Println("Usage: gxtrash restore [id]")
+			return
+		}
+		n, err := restoreTrashCommand(args[1])
+		recordAudit("gxtrash restore", []string{args[1]}, n, err)
+
+	case "empty":
+		var olderThan time.Duration
+		if len(args) >= 2 {
+			if args[1] != "--older-than" || len(args) < 3 {
+				fmt.Println("Usage: gxtrash empty [--older-than 7d]")
+				return
+			}
+			age, err := parseAge(args[2])
+			if err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+			olderThan = age
+		}
+		n, err := emptyTrash(olderThan)
+		recordAudit("gxtrash empty", args[1:], n, err)
+
+	default:
+		fmt.Printf("Unknown trash action: %s\n", args[0])
+		fmt.Println("Usage: gxtrash [list | restore <id> | empty [--older-than 7d]]")
+	}
+}
+
+// restoreTrashCommand restores an item and reports the outcome
+func restoreTrashCommand(id string) (int64, error) {
+	if isDryRun() {
+		dir, err := trashDir()
+		if err == nil {
+			var item trashItem
+			item, err = readTrashInfo(filepath.Join(dir, "info", filepath.Base(id)+".trashinfo"))
+			if err == nil {
+				dryRunf("would restore '%s' to '%s'", id, item.OriginalPath)
+				return 0, nil
+			}
+		}
+		fmt.Printf("Error restoring '%s': %v\n", id, err)
+		return 0, err
+	}
+
+	item, err := restoreFromTrash(id)
+	if err != nil {
+		fmt.Printf("Error restoring '%s': %v\n", id, err)
+		return 0, err
+	}
+	size, _ := dirSize(item.OriginalPath)
+	fmt.Printf("♻️  Restored '%s' to '%s'\n", id, item.OriginalPath)
+	return size, nil
+}
+
+// emptyTrash permanently deletes trash entries older than the given age (all if zero)
+func emptyTrash(olderThan time.Duration) (int64, error) {
+	items, err := listTrash()
+	if err != nil {
+		fmt.Printf("Error reading trash: %v\n", err)
+		return 0, err
+	}
+	dir, err := trashDir()
+	if err != nil {
+		fmt.Printf("Error reading trash: %v\n", err)
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	var expired []trashItem
+	for _, item := range items {
+		// An entry without a readable DeletionDate has an unknown age
+		if olderThan > 0 && (item.DeletedAt.IsZero() || item.DeletedAt.After(cutoff)) {
+			continue
+		}
+		expired = append(expired, item)
+	}
+
+	if isDryRun() {
+		for _, item := range expired {
+			dryRunf("would permanently delete '%s' (from %s)", item.ID, item.OriginalPath)
+		}
+		return 0, nil
+	}
+
+	if len(expired) > 0 && !confirmAction("Permanently delete %d trashed item(s)?", len(expired)) {
+		return 0, errCancelled
+	}
+
+	var freed int64
+	removed := 0
+	for _, item := range expired {
+		size, _ := dirSize(filepath.Join(dir, "files", item.ID))
+		if err := purgeTrashItem(dir, item.ID); err != nil {
+			fmt.Printf("Error removing '%s': %v\n", item.ID, err)
+			continue
+		}
+		freed += size
+		removed++
+	}
+
+	fmt.Printf("🗑️  Permanently deleted %d item(s), %d bytes freed\n", removed, freed)
+	return freed, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTrashListRestore(t *testing.T) {
+	t.Setenv("GX_CONFIG_DIR", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	trash := func(name, data string) string {
+		t.Helper()
+		if err := os.WriteFile(name, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		id, err := moveToTrash(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Lstat(name); !os.IsNotExist(err) {
+			t.Fatalf("%s still exists after moving it to the trash", name)
+		}
+		return id
+	}
+
+	first := trash("notes.txt", "first")
+	second := trash("notes.txt", "second")
+	if first != "notes.txt" || second != "notes.txt.2" {
+		t.Errorf("trash IDs = %q, %q; want %q, %q", first, second, "notes.txt", "notes.txt.2")
+	}
+
+	items, err := listTrash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listed []string
+	for _, item := range items {
+		if item.OriginalPath != filepath.Join(dir, "notes.txt") {
+			t.Errorf("item %s: original path = %q, want %q", item.ID, item.OriginalPath, filepath.Join(dir, "notes.txt"))
+		}
+		listed = append(listed, item.ID)
+	}
+	if got := strings.Join(listed, " "); got != "notes.txt notes.txt.2" {
+		t.Errorf("listed IDs = %q, want %q", got, "notes.txt notes.txt.2")
+	}
+
+	if _, err := restoreFromTrash(second); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile("notes.txt"); string(data) != "second" {
+		t.Errorf("restored notes.txt = %q, want %q", data, "second")
+	}
+
+	// The first copy would land on the file just restored
+	_, err = restoreFromTrash(first)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("restoring onto an existing path: err = %v, want already exists", err)
+	}
+	if data, _ := os.ReadFile("notes.txt"); string(data) != "second" {
+		t.Errorf("notes.txt = %q after refused restore, want %q", data, "second")
+	}
+	if items, _ := listTrash(); len(items) != 1 || items[0].ID != first {
+		t.Errorf("trash after refused restore = %v, want only %s", items, first)
+	}
+
+	if err := os.Remove("notes.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := restoreFromTrash(first); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile("notes.txt"); string(data) != "first" {
+		t.Errorf("restored notes.txt = %q, want %q", data, "first")
+	}
+	if items, _ := listTrash(); len(items) != 0 {
+		t.Errorf("trash still holds %d items after restoring everything", len(items))
+	}
+
+	if _, err := restoreFromTrash(first); err == nil {
+		t.Error("restoring an ID twice succeeded")
+	}
+	if _, err := restoreFromTrash("../notes.txt"); err == nil {
+		t.Error("restoring a path outside the trash succeeded")
+	}
+}
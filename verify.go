@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ==================== CHECKSUM VERIFICATION ====================
+
+// checksumEntry is one line of a checksum file
+type checksumEntry struct {
+	algorithm string
+	sum       string // lower-case hex digest
+	name      string
+}
+
+// checksumLengths is the algorithm assumed for an untagged line with a
+// digest of that many hex digits; 128 digits means SHA-512 unless -a blake2b
+// is given or the checksum file is named like B2SUMS
+var checksumLengths = map[int]string{8: "crc32", 32: "md5", 40: "sha1", 64: "sha256", 128: "sha512"}
+
+// taggedChecksumLine matches the BSD-style "SHA256 (name) = digest"
+var taggedChecksumLine = regexp.MustCompile(`^([A-Za-z0-9]+) \((.*)\) = ([0-9a-fA-F]+)$`)
+
+// errUnsafePath rejects checksum entries that point outside the working directory
+var errUnsafePath = errors.New("path outside the working directory")
+
+// verifyOptions holds the parsed gxverify arguments
+type verifyOptions struct {
+	algorithm string // algorithm for untagged lines ("" to go by digest length)
+	quiet     bool   // only print files that failed
+	jobs      int
+	files     []string
+}
+
+// parseVerifyArgs parses "gxverify [-a ALG] [-q] [-j N] checksum-file..."
+func parseVerifyArgs(args []string) (verifyOptions, error) {
+	opts := verifyOptions{jobs: runtime.NumCPU()}
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "-a", "-j":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("missing value for %s", arg)
+			}
+			i++
+			if arg == "-a" {
+				opts.algorithm = strings.ToLower(args[i])
+				if _, ok := hashAlgorithms[opts.algorithm]; !ok {
+					return opts, fmt.Errorf("unknown algorithm '%s' (use %s)", args[i], hashAlgorithmNames())
+				}
+				break
+			}
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				return opts, fmt.Errorf("invalid job count '%s'", args[i])
+			}
+			opts.jobs = n
+		case "-q", "--quiet":
+			opts.quiet = true
+		default:
+			opts.files = append(opts.files, arg)
+		}
+	}
+	if len(opts.files) == 0 {
+		return opts, fmt.Errorf("missing checksum file")
+	}
+	return opts, nil
+}
+
+// unescapeChecksumName reverses escapeChecksumName
+func unescapeChecksumName(name string) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] != '\\' {
+			b.WriteByte(name[i])
+			continue
+		}
+		i++
+		if i == len(name) {
+			return "", false
+		}
+		switch name[i] {
+		case '\\':
+			b.WriteByte('\\')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// tagAlgorithm returns the algorithm printed as tag by --tag
+func tagAlgorithm(tag string) (string, bool) {
+	for name, alg := range hashAlgorithms {
+		if alg.tag == tag {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// parseChecksumLine parses "digest  name", "digest *name" (binary mode) or
+// "ALG (name) = digest", each with a leading backslash when the name is
+// escaped. algorithm is used for untagged lines when it is not empty.
+func parseChecksumLine(line, algorithm string) (checksumEntry, bool) {
+	escaped := strings.HasPrefix(line, "\\")
+	if escaped {
+		line = line[1:]
+	}
+
+	var entry checksumEntry
+	if m := taggedChecksumLine.FindStringSubmatch(line); m != nil {
+		alg, ok := tagAlgorithm(m[1])
+		if !ok {
+			return entry, false
+		}
+		entry = checksumEntry{alg, strings.ToLower(m[3]), m[2]}
+	} else {
+		sum, name, ok := strings.Cut(line, " ")
+		if !ok || len(name) < 2 || (name[0] != ' ' && name[0] != '*') {
+			return entry, false
+		}
+		if algorithm == "" {
+			algorithm = checksumLengths[len(sum)]
+		}
+		entry = checksumEntry{algorithm, strings.ToLower(sum), name[1:]}
+	}
+
+	if _, err := hex.DecodeString(entry.sum); err != nil || entry.algorithm == "" {
+		return entry, false
+	}
+	if len(entry.sum) != 2*hashAlgorithms[entry.algorithm].new().Size() {
+		return entry, false
+	}
+	if escaped {
+		name, ok := unescapeChecksumName(entry.name)
+		if !ok {
+			return entry, false
+		}
+		entry.name = name
+	}
+	return entry, true
+}
+
+// readChecksumFile parses a checksum file, skipping blank and # comment
+// lines, and returns its entries and the number of malformed lines
+func readChecksumFile(path, algorithm string) ([]checksumEntry, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	var entries []checksumEntry
+	bad := 0
+	err = forEachLine(file, func(line, eol string) error {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			return nil
+		}
+		if entry, ok := parseChecksumLine(line, algorithm); ok {
+			entries = append(entries, entry)
+		} else {
+			bad++
+		}
+		return nil
+	})
+	return entries, bad, err
+}
+
+// verifyChecksumFile checks every file listed in a checksum file, printing
+// OK, FAILED or MISSING for each, and reports whether all of them matched
+func verifyChecksumFile(path string, opts verifyOptions) bool {
+	algorithm := opts.algorithm
+	if base := strings.ToLower(filepath.Base(path)); algorithm == "" &&
+		(strings.HasPrefix(base, "b2") || strings.Contains(base, "blake2")) {
+		algorithm = "blake2b"
+	}
+	entries, bad, err := readChecksumFile(path, algorithm)
+	if err != nil {
+		fmt.Printf("Error reading checksum file '%s': %v\n", path, err)
+		return false
+	}
+	if len(entries) == 0 {
+		fmt.Printf("❌ %s: no properly formatted checksum lines found\n", path)
+		return false
+	}
+
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.name
+	}
+	prog := newProgress("Verifying", totalSize(names), int64(len(entries)))
+	results := hashParallel(len(entries), opts.jobs, func(i int) (string, error) {
+		defer prog.addFile()
+		if isPathTraversal(entries[i].name) {
+			return "", errUnsafePath
+		}
+		return hashFile(entries[i].name, entries[i].algorithm, prog)
+	})
+
+	ok, failed, missing := 0, 0, 0
+	for i, entry := range entries {
+		res := <-results[i]
+		name, escaped := escapeChecksumName(entry.name)
+		if escaped {
+			name = "\\" + name
+		}
+		switch {
+		case os.IsNotExist(res.err):
+			missing++
+			prog.printf("%s: MISSING\n", name)
+		case res.err != nil:
+			failed++
+			prog.pause()
+			fmt.Fprintf(os.Stderr, "gxverify: %s: %v\n", entry.name, res.err)
+			prog.printf("%s: FAILED open or read\n", name)
+		case res.sum != entry.sum:
+			failed++
+			prog.printf("%s: FAILED\n", name)
+		default:
+			ok++
+			if !opts.quiet {
+				prog.printf("%s: OK\n", name)
+			}
+		}
+	}
+	prog.finish()
+
+	if bad > 0 {
+		fmt.Printf("⚠️  %s: %d line(s) improperly formatted\n", path, bad)
+	}
+	if failed == 0 && missing == 0 {
+		fmt.Printf("✅ %s: all %d file(s) OK\n", path, ok)
+		return true
+	}
+	fmt.Printf("❌ %s: %d FAILED, %d MISSING, %d OK\n", path, failed, missing, ok)
+	return false
+}
+
+// gxverify checks files against one or more checksum files and sets a
+// non-zero exit status when any file is missing or does not match
+func gxverify(opts verifyOptions) {
+	for _, path := range opts.files {
+		if !validatePath(path) {
+			exitStatus = 1
+			continue
+		}
+		if !verifyChecksumFile(path, opts) {
+			exitStatus = 1
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseChecksumLine(t *testing.T) {
+	md5 := "900150983cd24fb0d6963f7d28e17f72"
+	sha256 := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	tests := []struct {
+		name      string
+		line      string
+		algorithm string
+		want      checksumEntry
+		ok        bool
+	}{
+		{"text mode", sha256 + "  file.txt", "", checksumEntry{"sha256", sha256, "file.txt"}, true},
+		{"binary mode", sha256 + " *file.txt", "", checksumEntry{"sha256", sha256, "file.txt"}, true},
+		{"md5 by length", md5 + "  a b.txt", "", checksumEntry{"md5", md5, "a b.txt"}, true},
+		{"upper-case digest", strings.ToUpper(md5) + "  x", "", checksumEntry{"md5", md5, "x"}, true},
+		{"name with two spaces", md5 + "  a  b", "", checksumEntry{"md5", md5, "a  b"}, true},
+		{"tagged", "SHA256 (file.txt) = " + sha256, "", checksumEntry{"sha256", sha256, "file.txt"}, true},
+		{"tagged name with parens", "MD5 (a (1).txt) = " + md5, "", checksumEntry{"md5", md5, "a (1).txt"}, true},
+		{"escaped newline", `\` + md5 + `  a\nb`, "", checksumEntry{"md5", md5, "a\nb"}, true},
+		{"escaped backslash", `\` + md5 + `  a\\b`, "", checksumEntry{"md5", md5, `a\b`}, true},
+		{"escaped tagged", `\MD5 (a\rb) = ` + md5, "", checksumEntry{"md5", md5, "a\rb"}, true},
+		{"unescaped backslash kept", md5 + `  a\nb`, "", checksumEntry{"md5", md5, `a\nb`}, true},
+		{"bad escape", `\` + md5 + `  a\qb`, "", checksumEntry{}, false},
+		{"trailing backslash", `\` + md5 + `  a\`, "", checksumEntry{}, false},
+		{"algorithm given", md5 + "  x", "md5", checksumEntry{"md5", md5, "x"}, true},
+		{"length mismatch for algorithm", md5 + "  x", "sha256", checksumEntry{}, false},
+		{"unknown tag", "SHA3 (x) = " + sha256, "", checksumEntry{}, false},
+		{"tag length mismatch", "SHA256 (x) = " + md5, "", checksumEntry{}, false},
+		{"unknown length", "abcdef  x", "", checksumEntry{}, false},
+		{"not hex", strings.Repeat("z", 32) + "  x", "", checksumEntry{}, false},
+		{"single space", md5 + " x", "", checksumEntry{}, false},
+		{"missing name", md5 + "  ", "", checksumEntry{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseChecksumLine(tt.line, tt.algorithm)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("%s: parseChecksumLine(%q) = %+v, %v; want %+v, %v", tt.name, tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestChecksumNameRoundTrip(t *testing.T) {
+	for _, name := range []string{"plain", "a\nb", `a\b`, "a\r\n", `\\n`, "ü\\"} {
+		escaped, _ := escapeChecksumName(name)
+		got, ok := unescapeChecksumName(escaped)
+		if !ok || got != name {
+			t.Errorf("unescape(escape(%q)) = %q, %v", name, got, ok)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ==================== VIEW OPTIONS ====================
+
+// viewOptions holds the arguments shared by gxcat, gxhead and gxtail
+type viewOptions struct {
+	lines   int   // gxhead/gxtail: number of lines to show
+	bytes   int64 // gxhead/gxtail: bytes to show instead of lines (-1 when unset)
+	follow  bool  // gxtail: keep printing data as it is appended
+	number  bool  // gxcat: number the lines
+	showAll bool  // show tabs, control characters, invalid UTF-8 and line ends
+	from    int   // gxcat file:FROM-TO: first line to show (0 when unset)
+	to      int   // last line to show (0 for the end of the file)
+}
+
+// errStopReading ends a forEachLine loop early without reporting an error
+var errStopReading = errors.New("stop reading")
+
+// parseViewArgs parses the options of gxcat, gxhead and gxtail:
+//
+//	gxcat  [-n] [-A] file[:FROM-TO]...
+//	gxhead [-n N | -N] [-c BYTES] [-A] file...
+//	gxtail [-n N | -N] [-c BYTES] [-f] [-A] file...
+//
+// For gxcat, -n numbers lines; for gxhead and gxtail it takes a count.
+func parseViewArgs(command string, args []string) (viewOptions, []string, error) {
+	opts := viewOptions{lines: 10, bytes: -1}
+	counts := command != "gxcat"
+	var files []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			files = append(files, args[i+1:]...)
+			i = len(args)
+		case arg == "-n" && !counts:
+			opts.number = true
+		case arg == "-A" || arg == "--show-all":
+			opts.showAll = true
+		case (arg == "-n" || arg == "-c") && counts:
+			if i+1 >= len(args) {
+				return opts, nil, fmt.Errorf("missing count for %s", arg)
+			}
+			i++
+			n, err := strconv.ParseInt(args[i], 10, 64)
+			if err != nil || n < 0 {
+				return opts, nil, fmt.Errorf("invalid count '%s' for %s", args[i], arg)
+			}
+			if arg == "-n" {
+				opts.lines, opts.bytes = int(n), -1
+			} else {
+				opts.bytes = n
+			}
+		case (arg == "-f" || arg == "--follow") && command == "gxtail":
+			opts.follow = true
+		case counts && len(arg) > 1 && arg[0] == '-' && isAllDigits(arg[1:]):
+			// gxhead -20 is short for gxhead -n 20
+			n, err := strconv.Atoi(arg[1:])
+			if err != nil {
+				return opts, nil, fmt.Errorf("invalid count '%s'", arg)
+			}
+			opts.lines, opts.bytes = n, -1
+		case len(arg) > 1 && arg[0] == '-':
+			return opts, nil, fmt.Errorf("unknown option %s for %s", arg, command)
+		default:
+			files = append(files, arg)
+		}
+	}
+	if len(files) == 0 {
+		return opts, nil, fmt.Errorf("missing filename")
+	}
+	return opts, files, nil
+}
+
+func isAllDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// lineRangeSuffix matches ":120-180", ":120-" and ":120" at the end of a name
+var lineRangeSuffix = regexp.MustCompile(`^(.+):(\d+)(?:(-)(\d*))?$`)
+
+// parseLineRange splits "file:FROM-TO" into the file name and line range.
+// A name that exists as written is never split.
+func parseLineRange(arg string) (string, int, int, error) {
+	m := lineRangeSuffix.FindStringSubmatch(arg)
+	if m == nil {
+		return arg, 0, 0, nil
+	}
+	if _, err := os.Stat(arg); err == nil {
+		return arg, 0, 0, nil
+	}
+	from, _ := strconv.Atoi(m[2])
+	to := from
+	if m[3] != "" {
+		to = 0
+		if m[4] != "" {
+			to, _ = strconv.Atoi(m[4])
+		}
+	}
+	if from < 1 || (to != 0 && to < from) {
+		return arg, 0, 0, fmt.Errorf("invalid line range '%s'", arg[len(m[1])+1:])
+	}
+	return m[1], from, to, nil
+}
+
+// showNonPrinting makes a line's invisible content visible: tabs and other
+// control characters as ^I, ^M, ..., invalid UTF-8 bytes as \xHH, invisible
+// Unicode characters (byte order marks, zero-width and no-break spaces) as
+// <U+XXXX>, and the end of the line as $
+func showNonPrinting(line, eol string) string {
+	var b strings.Builder
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&b, "\\x%02X", line[i])
+		case r < 0x20:
+			b.WriteByte('^')
+			b.WriteByte(byte(r) + '@')
+		case r == 0x7f:
+			b.WriteString("^?")
+		case r != ' ' && !unicode.IsPrint(r):
+			fmt.Fprintf(&b, "<U+%04X>", r)
+		default:
+			b.WriteString(line[i : i+size])
+		}
+		i += size
+	}
+	if eol != "" {
+		b.WriteString("$")
+	}
+	b.WriteString(eol)
+	return b.String()
+}
+
+// copyView copies r to w, making non-printing characters visible when
+// showAll is set, and reports what was written
+func copyView(w io.Writer, r io.Reader, showAll bool) (*newlineTracker, error) {
+	tracker := &newlineTracker{w: w}
+	if !showAll {
+		_, err := io.Copy(tracker, r)
+		return tracker, err
+	}
+	err := forEachLine(r, func(line, eol string) error {
+		_, err := io.WriteString(tracker, showNonPrinting(line, eol))
+		return err
+	})
+	return tracker, err
+}
+
+// viewLines prints a file line by line for gxcat -n, -A and file:FROM-TO.
+// Lines before the range are still passed through the syntax highlighter so
+// comments and strings that started earlier are colored correctly.
+func viewLines(r io.Reader, filename string, lex *lexer, opts viewOptions) {
+	if opts.showAll || !colorsEnabled() {
+		lex = nil
+	}
+	st := lexNormal
+	from := max(opts.from, 1)
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	if opts.from > 0 {
+		last := "end"
+		if opts.to > 0 {
+			last = strconv.Itoa(opts.to)
+		}
+		fmt.Fprintf(out, "\n--- %s (lines %d-%s) ---\n", filename, from, last)
+	} else {
+		fmt.Fprintf(out, "\n--- %s ---\n", filename)
+	}
+
+	num, shown := 0, 0
+	err := forEachLine(r, func(line, eol string) error {
+		num++
+		if opts.to > 0 && num > opts.to {
+			return errStopReading
+		}
+		text := strings.TrimSuffix(line, "\r")
+		var spans []span
+		if lex != nil {
+			spans = lex.spans(lex, text, &st)
+		}
+		if num < from {
+			return nil
+		}
+		shown++
+		if opts.number {
+			fmt.Fprintf(out, "%6d  ", num)
+		}
+		switch {
+		case opts.showAll:
+			out.WriteString(strings.TrimSuffix(showNonPrinting(line, eol), "\n"))
+		case lex != nil:
+			out.WriteString(colorize(text, spans) + line[len(text):])
+		default:
+			out.WriteString(line)
+		}
+		return out.WriteByte('\n')
+	})
+	if err != nil && err != errStopReading {
+		fmt.Fprintf(out, "Error reading file '%s': %v\n", filename, err)
+		return
+	}
+
+	switch {
+	case opts.from == 0:
+		fmt.Fprintf(out, "--- End of file (%d lines) ---\n", num)
+	case shown == 0:
+		fmt.Fprintf(out, "(file has only %d lines)\n", num)
+	default:
+		fmt.Fprintf(out, "--- Showed lines %d-%d of %s ---\n", from, from+shown-1, filename)
+	}
+}